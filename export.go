@@ -0,0 +1,149 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/visiosto/bifrost/internal/config"
+	"github.com/visiosto/bifrost/internal/export"
+	"github.com/visiosto/bifrost/internal/storage"
+)
+
+// runExport runs the "export" command that writes the stored submissions of
+// a form to stdout as CSV or NDJSON.
+func runExport(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	cfgPath := flags.String("config", "/etc/bifrost.json", "path to the config file")
+	siteID := flags.String("site", "", "ID of the site of the form")
+	formID := flags.String("form", "", "ID of the form to export")
+	format := flags.String("format", export.FormatCSV, "output format, either csv or ndjson")
+	from := flags.String("from", "", "export submissions received on or after the given date or RFC 3339 time")
+	to := flags.String("to", "", "export submissions received before the given date or RFC 3339 time")
+	verdict := flags.String("verdict", storage.VerdictHam, "export submissions with the given spam verdict, or all")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	if *siteID == "" || *formID == "" {
+		return fmt.Errorf(
+			"%w: usage: bifrost export -site <id> -form <id> [-format csv|ndjson] [-verdict ham|spam|all]",
+			errUsage,
+		)
+	}
+
+	cfg, err := config.Load(*cfgPath)
+	if err != nil {
+		return err
+	}
+
+	form, err := findForm(cfg, *siteID, *formID)
+	if err != nil {
+		return err
+	}
+
+	// Pin the upper bound so that the submissions received during the export
+	// do not shift the pages.
+	filter := &storage.Filter{Site: *siteID, Form: *formID, To: time.Now()} //nolint:exhaustruct // filled below
+
+	switch *verdict {
+	case storage.VerdictHam, storage.VerdictSpam:
+		filter.SpamVerdict = *verdict
+	case "all":
+	default:
+		return fmt.Errorf("%w: verdict must be ham, spam, or all", errUsage)
+	}
+
+	filter.From, err = parseDate(*from)
+	if err != nil {
+		return err
+	}
+
+	if *to != "" {
+		filter.To, err = parseDate(*to)
+		if err != nil {
+			return err
+		}
+	}
+
+	exporter, err := export.New(form)
+	if err != nil {
+		return err
+	}
+
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	defer store.Close() //nolint:errcheck // nothing to do with the error at exit
+
+	out := bufio.NewWriter(os.Stdout)
+
+	err = exporter.Write(ctx, out, store, filter, *format)
+	if err != nil {
+		return fmt.Errorf("failed to export submissions: %w", err)
+	}
+
+	err = out.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	return nil
+}
+
+func findForm(cfg *config.Config, siteID, formID string) (*config.Form, error) {
+	for _, site := range cfg.Sites {
+		if site.ID != siteID {
+			continue
+		}
+
+		for i := range site.Forms {
+			if site.Forms[i].ID == formID {
+				return &site.Forms[i], nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w: unknown form %q of site %q", errUsage, formID, siteID)
+}
+
+// parseDate parses a date or an RFC 3339 time from a command-line flag. An
+// empty string results in the zero time.
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err == nil {
+		return t, nil
+	}
+
+	t, err = time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid date %q", errUsage, s)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/visiosto/bifrost/internal/config"
+	"github.com/visiosto/bifrost/internal/storage"
+)
+
+var errUsage = errors.New("invalid usage")
+
+// runGDPR runs the "gdpr" command that exports or erases all of the stored
+// submissions that contain the given email address.
+func runGDPR(ctx context.Context, args []string) error {
+	if len(args) == 0 || (args[0] != "export" && args[0] != "erase") {
+		return fmt.Errorf("%w: usage: bifrost gdpr export|erase -email <address> [-config <path>]", errUsage)
+	}
+
+	action := args[0]
+	flags := flag.NewFlagSet("gdpr "+action, flag.ExitOnError)
+	cfgPath := flags.String("config", "/etc/bifrost.json", "path to the config file")
+	email := flags.String("email", "", "email address of the data subject")
+
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	if *email == "" {
+		return fmt.Errorf("%w: missing -email", errUsage)
+	}
+
+	cfg, err := config.Load(*cfgPath)
+	if err != nil {
+		return err
+	}
+
+	store, err := openStore(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	defer store.Close() //nolint:errcheck // nothing to do with the error at exit
+
+	filter := &storage.Filter{Value: *email} //nolint:exhaustruct // only by value
+
+	if action == "erase" {
+		var n int64
+
+		n, err = store.DeleteMatching(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("failed to erase submissions: %w", err)
+		}
+
+		_, err = fmt.Fprintf(os.Stdout, "deleted %d submissions\n", n)
+		if err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+
+		return nil
+	}
+
+	subs, err := store.List(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to find submissions: %w", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	err = enc.Encode(map[string]any{"submissions": subs})
+	if err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	return nil
+}
+
+// openStore opens the storage configured in cfg and fails if there is none.
+//
+//nolint:ireturn // storage is chosen by config
+func openStore(ctx context.Context, cfg *config.Config) (storage.Store, error) {
+	store, err := storage.Open(ctx, &cfg.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open storage: %w", err)
+	}
+
+	if store == nil {
+		return nil, fmt.Errorf("%w: storage is not configured", errUsage)
+	}
+
+	return store, nil
+}
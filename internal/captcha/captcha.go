@@ -0,0 +1,171 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package captcha verifies the CAPTCHA tokens of the form submissions with
+// the siteverify APIs of the supported providers.
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/visiosto/bifrost/internal/config"
+)
+
+// Default siteverify endpoints of the providers.
+const (
+	TurnstileURL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+	HCaptchaURL  = "https://api.hcaptcha.com/siteverify"
+	ReCaptchaURL = "https://www.google.com/recaptcha/api/siteverify"
+)
+
+const (
+	requestTimeout   = 10 * time.Second
+	maxResponseBytes = 1 << 16
+)
+
+var (
+	// ErrFailed is returned when the token is not accepted.
+	ErrFailed = errors.New("captcha verification failed")
+
+	// ErrUnavailable is returned when the token could not be verified.
+	ErrUnavailable = errors.New("captcha verification unavailable")
+)
+
+// Verifier verifies the CAPTCHA tokens of a form.
+type Verifier struct {
+	client   *http.Client
+	url      string
+	secret   string
+	hosts    []string
+	minScore float64
+	score    bool
+}
+
+type siteverifyResponse struct {
+	Hostname   string   `json:"hostname"`
+	ErrorCodes []string `json:"error-codes"` //nolint:tagliatelle // defined by the providers
+	Score      *float64 `json:"score"`
+	Success    bool     `json:"success"`
+}
+
+// New returns a Verifier for the CAPTCHA config of a form of the site.
+func New(site *config.Site, cfg *config.Captcha) *Verifier {
+	endpoint := cfg.VerifyURL
+	if endpoint == "" {
+		switch cfg.Provider {
+		case config.CaptchaTurnstile:
+			endpoint = TurnstileURL
+		case config.CaptchaHCaptcha:
+			endpoint = HCaptchaURL
+		case config.CaptchaReCaptcha:
+			endpoint = ReCaptchaURL
+		}
+	}
+
+	// The hostname is checked only when the allowed origins are restricted.
+	var hosts []string
+
+	if !slices.Contains(site.AllowedOrigins, "*") {
+		for _, origin := range site.AllowedOrigins {
+			u, err := url.Parse(origin)
+			if err == nil && u.Hostname() != "" {
+				hosts = append(hosts, u.Hostname())
+			}
+		}
+	}
+
+	return &Verifier{
+		client:   &http.Client{Timeout: requestTimeout}, //nolint:exhaustruct // use defaults
+		url:      endpoint,
+		secret:   site.CaptchaSecret,
+		hosts:    hosts,
+		minScore: cfg.MinScore,
+		score:    cfg.Provider == config.CaptchaReCaptcha && cfg.MinScore > 0,
+	}
+}
+
+// Verify verifies the token with the siteverify API. It returns an error
+// wrapping [ErrFailed] if the token is rejected and [ErrUnavailable] if
+// the API could not be reached.
+func (v *Verifier) Verify(ctx context.Context, token, remoteIP string) error {
+	if token == "" {
+		return fmt.Errorf("%w: missing token", ErrFailed)
+	}
+
+	form := url.Values{}
+	form.Set("secret", v.secret)
+	form.Set("response", token)
+
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("%w: failed to create request: %w", ErrUnavailable, err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+
+	defer resp.Body.Close() //nolint:errcheck // the body is fully read below
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: siteverify responded with %s", ErrUnavailable, resp.Status)
+	}
+
+	var result siteverifyResponse
+
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&result)
+	if err != nil {
+		return fmt.Errorf("%w: failed to decode siteverify response: %w", ErrUnavailable, err)
+	}
+
+	return v.check(&result)
+}
+
+func (v *Verifier) check(result *siteverifyResponse) error {
+	if !result.Success {
+		return fmt.Errorf("%w: %s", ErrFailed, strings.Join(result.ErrorCodes, ", "))
+	}
+
+	if len(v.hosts) > 0 && !slices.Contains(v.hosts, result.Hostname) {
+		return fmt.Errorf("%w: unexpected hostname %q", ErrFailed, result.Hostname)
+	}
+
+	if v.score {
+		if result.Score == nil {
+			return fmt.Errorf("%w: missing score", ErrFailed)
+		}
+
+		if *result.Score < v.minScore {
+			return fmt.Errorf("%w: score %.2f is below %.2f", ErrFailed, *result.Score, v.minScore)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package captcha_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/visiosto/bifrost/internal/captcha"
+	"github.com/visiosto/bifrost/internal/config"
+)
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		wantErr  error
+		name     string
+		provider string
+		response string
+		status   int
+		minScore float64
+	}{
+		{
+			name:     "success",
+			provider: config.CaptchaTurnstile,
+			status:   http.StatusOK,
+			response: `{"success": true, "hostname": "a.example"}`,
+			wantErr:  nil,
+		},
+		{
+			name:     "rejected",
+			provider: config.CaptchaHCaptcha,
+			status:   http.StatusOK,
+			response: `{"success": false, "error-codes": ["invalid-input-response"]}`,
+			wantErr:  captcha.ErrFailed,
+		},
+		{
+			name:     "hostname mismatch",
+			provider: config.CaptchaTurnstile,
+			status:   http.StatusOK,
+			response: `{"success": true, "hostname": "evil.example"}`,
+			wantErr:  captcha.ErrFailed,
+		},
+		{
+			name:     "score above threshold",
+			provider: config.CaptchaReCaptcha,
+			status:   http.StatusOK,
+			response: `{"success": true, "hostname": "a.example", "score": 0.9}`,
+			minScore: 0.5,
+			wantErr:  nil,
+		},
+		{
+			name:     "score below threshold",
+			provider: config.CaptchaReCaptcha,
+			status:   http.StatusOK,
+			response: `{"success": true, "hostname": "a.example", "score": 0.3}`,
+			minScore: 0.5,
+			wantErr:  captcha.ErrFailed,
+		},
+		{
+			name:     "missing score",
+			provider: config.CaptchaReCaptcha,
+			status:   http.StatusOK,
+			response: `{"success": true, "hostname": "a.example"}`,
+			minScore: 0.5,
+			wantErr:  captcha.ErrFailed,
+		},
+		{
+			name:     "provider error",
+			provider: config.CaptchaTurnstile,
+			status:   http.StatusInternalServerError,
+			response: `{}`,
+			wantErr:  captcha.ErrUnavailable,
+		},
+		{
+			name:     "malformed response",
+			provider: config.CaptchaTurnstile,
+			status:   http.StatusOK,
+			response: `not json`,
+			wantErr:  captcha.ErrUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// The stand-in for the siteverify API checks that it receives
+			// the secret, the token, and the client IP address.
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				err := r.ParseForm()
+				if err != nil {
+					t.Errorf("failed to parse siteverify request: %v", err)
+				}
+
+				for key, want := range map[string]string{"secret": "s3cret", "response": "tok", "remoteip": "192.0.2.1"} {
+					if got := r.PostForm.Get(key); got != want {
+						t.Errorf("siteverify request %s = %q, want %q", key, got, want)
+					}
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer srv.Close()
+
+			site := &config.Site{ //nolint:exhaustruct // only the CAPTCHA settings are needed
+				AllowedOrigins: []string{"https://a.example"},
+				CaptchaSecret:  "s3cret",
+			}
+			cfg := &config.Captcha{
+				Provider:   tt.provider,
+				TokenField: "captcha",
+				VerifyURL:  srv.URL,
+				MinScore:   tt.minScore,
+			}
+
+			err := captcha.New(site, cfg).Verify(t.Context(), "tok", "192.0.2.1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyMissingToken(t *testing.T) {
+	t.Parallel()
+
+	site := &config.Site{ //nolint:exhaustruct // only the CAPTCHA settings are needed
+		AllowedOrigins: []string{"*"},
+		CaptchaSecret:  "s3cret",
+	}
+	cfg := &config.Captcha{
+		Provider:   config.CaptchaTurnstile,
+		TokenField: "captcha",
+		VerifyURL:  "http://127.0.0.1:0",
+		MinScore:   0,
+	}
+
+	err := captcha.New(site, cfg).Verify(t.Context(), "", "")
+	if !errors.Is(err, captcha.ErrFailed) {
+		t.Errorf("Verify() error = %v, want %v", err, captcha.ErrFailed)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package client contains helpers for identifying the client of a request.
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+)
+
+type ctxKey struct{}
+
+// IP returns the IP address of the client that made the request. It is
+// the address resolved by [WithIP] if the request has passed through it, and
+// otherwise the remote address of the connection.
+func IP(r *http.Request) string {
+	if ip, ok := r.Context().Value(ctxKey{}).(string); ok {
+		return ip
+	}
+
+	return remoteHost(r)
+}
+
+// Addr returns the parsed IP address of the client that made the request.
+func Addr(r *http.Request) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(IP(r))
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("failed to parse client IP address: %w", err)
+	}
+
+	return addr.Unmap(), nil
+}
+
+// WithIP returns a shallow copy of the request with the client IP address
+// resolved. The address set by the reverse proxy in the X-Real-IP header
+// takes precedence, but only if the request comes from one of the trusted
+// proxies. If there are no trusted proxies, the header is trusted from all
+// addresses to keep the behavior of the versions before the trusted proxies
+// were added.
+func WithIP(r *http.Request, trustedProxies []netip.Prefix) *http.Request {
+	ip := remoteHost(r)
+
+	if realIP := r.Header.Get("X-Real-IP"); realIP != "" && isTrusted(ip, trustedProxies) {
+		ip = realIP
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), ctxKey{}, ip))
+}
+
+func isTrusted(ip string, trustedProxies []netip.Prefix) bool {
+	if len(trustedProxies) == 0 {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+
+	return slices.ContainsFunc(trustedProxies, func(p netip.Prefix) bool { return p.Contains(addr) })
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -23,6 +23,24 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
+)
+
+// Storage types.
+const (
+	StorageSQLite = "sqlite"
+)
+
+// Idempotency backends.
+const (
+	IdempotencyMemory  = "memory"
+	IdempotencyStorage = "storage"
+)
+
+// Default values for the optional config values.
+const (
+	defaultReadinessCacheSeconds   = 30
+	defaultReadinessTimeoutSeconds = 5
 )
 
 var errConfig = errors.New("invalid config")
@@ -35,6 +53,45 @@ type Config struct {
 	Sites      []Site     `json:"sites"`
 	LogLevel   slog.Level `json:"logLevel"` // defaults to 0 which is info
 	RateLimit  RateLimit  `json:"rateLimit"`
+	Admin      Admin      `json:"admin"`
+	Tracing    Tracing    `json:"tracing"`
+	Redaction  Redaction  `json:"redaction"`
+	Readiness  Readiness  `json:"readiness"`
+	Storage    Storage    `json:"storage"`
+	Bans       Bans       `json:"bans"`
+
+	// Idempotency is the config for detecting the repeated submissions.
+	Idempotency Idempotency `json:"idempotency"`
+
+	// BlockedCIDRs are the networks that are denied access to all sites.
+	BlockedCIDRs []string `json:"blockedCIDRs"` //nolint:tagliatelle // CIDR is an acronym
+
+	// AllowedCIDRs are the networks that are exempt from the blocked networks
+	// and the bans on all sites.
+	AllowedCIDRs []string `json:"allowedCIDRs"` //nolint:tagliatelle // CIDR is an acronym
+
+	// TrustedProxies are the networks of the reverse proxies that are trusted
+	// to set the client IP address in the X-Real-IP header. The header is
+	// ignored in the requests from the other addresses.
+	//
+	// If this is empty, the header is trusted from all addresses like in
+	// the earlier versions, and a warning is logged on start-up. As anyone
+	// can then set the client IP address, the deployments should set this to
+	// the networks of their reverse proxies, for example "127.0.0.1/32" if
+	// the proxy runs on the same host. The deployments without a reverse
+	// proxy should set this to an address that never connects, such as
+	// "0.0.0.0/32", so that the header is always ignored.
+	TrustedProxies []string `json:"trustedProxies"`
+
+	// LogFormat is the format of the log output, either "text" or "json".
+	// Defaults to text.
+	LogFormat LogFormat `json:"logFormat"`
+
+	// LogOutput is where the logs are written. It is either "stdout",
+	// "stderr", or a path to a file. Log files are reopened when
+	// the program receives SIGUSR1 so that they can be rotated. Defaults to
+	// stdout.
+	LogOutput string `json:"logOutput"`
 
 	// MaxBodyBytes is the default maximum size of the message body in bytes.
 	MaxBodyBytes int64 `json:"maxBodyBytes"`
@@ -44,6 +101,96 @@ type Config struct {
 	DebugHeaders bool `json:"debugHeaders"`
 }
 
+// Idempotency is the config for detecting the repeated submissions. A request
+// is a repeat if it has the same "Idempotency-Key" header as an earlier
+// request to the form or, without the header, the same body and client IP
+// address. The repeats get the original response without running
+// the notifiers again.
+type Idempotency struct {
+	// Backend is where the responses are remembered, either "memory" or
+	// "storage" to keep them in the configured storage over restarts.
+	// Defaults to "memory".
+	Backend string `json:"backend"`
+
+	// WindowSeconds is how long the responses are remembered. The repeats
+	// are not detected if this is zero.
+	WindowSeconds int `json:"windowSeconds"`
+}
+
+// Admin is the config for the admin listener. The admin listener is separate
+// from the public server so that it can be bound only to a private interface.
+type Admin struct {
+	// ListenAddr is the address the admin server should bind to. The admin
+	// server is not started if this is empty.
+	ListenAddr string `json:"listenAddress"`
+
+	// Token is the bearer token required by the admin API. The admin API is
+	// enabled only if this is set. The endpoints for the stored submissions
+	// also require the storage to be configured.
+	Token string `json:"token"`
+
+	// Metrics controls whether the Prometheus metrics are served at
+	// the "/metrics" endpoint of the admin server.
+	Metrics bool `json:"metrics"`
+}
+
+// Tracing is the config for exporting OpenTelemetry traces.
+type Tracing struct {
+	// Endpoint is the URL of the OTLP/HTTP traces endpoint, for example
+	// "http://localhost:4318/v1/traces". The traces are not exported if this
+	// is empty.
+	Endpoint string `json:"endpoint"`
+
+	// Headers are additional HTTP headers sent with the exported traces, for
+	// example for authenticating to the collector.
+	Headers map[string]string `json:"headers"`
+
+	// SampleRatio is the ratio of the new traces that are sampled. Traces that
+	// are started by the caller follow the caller's sampling decision. Defaults
+	// to 1 which samples all of the traces, and 0 samples only the traces that
+	// the caller has sampled.
+	SampleRatio *float64 `json:"sampleRatio"`
+}
+
+// Redaction is the config for removing secrets and personal data from the logs.
+// The tokens are always logged only as hash prefixes.
+type Redaction struct {
+	// Headers are the names of the additional headers whose values are
+	// redacted when the request headers are printed to the log. The token,
+	// authorization, and cookie headers are always redacted.
+	Headers []string `json:"headers"`
+
+	// IPHashKey is the key for hashing the client IP addresses. If it is
+	// empty, a random key is generated on each start.
+	IPHashKey string `json:"ipHashKey"`
+
+	// HashClientIPs controls whether the client IP addresses are logged as
+	// keyed hashes instead of the plain addresses.
+	HashClientIPs bool `json:"hashClientIps"`
+}
+
+// Readiness is the config for the readiness checks.
+type Readiness struct {
+	// CacheSeconds is the time in seconds for which the result of each check
+	// is cached. Defaults to 30.
+	CacheSeconds int `json:"cacheSeconds"`
+
+	// TimeoutSeconds is the time in seconds after which a single check fails.
+	// Defaults to 5.
+	TimeoutSeconds int `json:"timeoutSeconds"`
+}
+
+// Storage is the config for the submission storage. The submissions are stored
+// only for the forms that have storing enabled.
+type Storage struct {
+	// Type is the type of the storage. The only supported type is "sqlite".
+	// The storage is disabled if this is empty.
+	Type string `json:"type"`
+
+	// Path is the path to the database file.
+	Path string `json:"path"`
+}
+
 // RateLimit is the global rate limit config.
 type RateLimit struct {
 	PerIPSiteMinute int `json:"perIpSiteMinute"`
@@ -55,6 +202,40 @@ type Site struct {
 	Token          string   `json:"token"`
 	AllowedOrigins []string `json:"allowedOrigins"`
 	Forms          []Form   `json:"forms"`
+
+	// BlockedCIDRs are the networks that are denied access to the site.
+	BlockedCIDRs []string `json:"blockedCIDRs"` //nolint:tagliatelle // CIDR is an acronym
+
+	// AllowedCIDRs are the networks that are exempt from the blocked networks
+	// and the bans on the site.
+	AllowedCIDRs []string `json:"allowedCIDRs"` //nolint:tagliatelle // CIDR is an acronym
+
+	// CaptchaSecret is the secret key of the site for the CAPTCHA provider
+	// used by its forms.
+	CaptchaSecret string `json:"captchaSecret"`
+
+	// TimeTrapSecret is the key for signing the form timestamps of the site.
+	TimeTrapSecret string `json:"timeTrapSecret"`
+
+	// PowSecret is the key for signing the proof-of-work challenges of
+	// the site.
+	PowSecret string `json:"powSecret"`
+
+	// RetentionDays is the number of days after which the stored submissions
+	// of the site are deleted. It can be overridden per form. Zero keeps
+	// the submissions until they are deleted manually.
+	RetentionDays int `json:"retentionDays"`
+}
+
+// Retention returns the retention period of the stored submissions of
+// the given form of the site. Zero means that the submissions are kept.
+func (s *Site) Retention(form *Form) time.Duration {
+	days := s.RetentionDays
+	if form.RetentionDays > 0 {
+		days = form.RetentionDays
+	}
+
+	return time.Duration(days) * 24 * time.Hour
 }
 
 // Load loads the config from the config file at the given path.
@@ -83,6 +264,17 @@ func Load(path string) (*Config, error) {
 	return &cfg, nil
 }
 
+// CheckListenAddrs checks that the admin server does not listen on the same
+// address as the server. It must be called again if the addresses are
+// changed after the config is loaded.
+func (c *Config) CheckListenAddrs() error {
+	if c.Admin.ListenAddr != "" && c.Admin.ListenAddr == c.ListenAddr {
+		return fmt.Errorf("%w: admin listenAddress must differ from listenAddress", errConfig)
+	}
+
+	return nil
+}
+
 func (c *Config) validate() error {
 	if c.ListenAddr == "" {
 		return fmt.Errorf("%w: empty listenAddress", errConfig)
@@ -96,6 +288,58 @@ func (c *Config) validate() error {
 		return fmt.Errorf("%w: global rate limit perIpSiteMinute must be greater than zero", errConfig)
 	}
 
+	err := c.CheckListenAddrs()
+	if err != nil {
+		return err
+	}
+
+	if c.LogOutput == "" {
+		c.LogOutput = LogOutputStdout
+	}
+
+	if c.Tracing.SampleRatio == nil {
+		ratio := 1.0
+		c.Tracing.SampleRatio = &ratio
+	}
+
+	if *c.Tracing.SampleRatio < 0 || *c.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("%w: tracing sampleRatio must be between 0 and 1", errConfig)
+	}
+
+	if c.Readiness.CacheSeconds < 0 || c.Readiness.TimeoutSeconds < 0 {
+		return fmt.Errorf("%w: readiness cacheSeconds and timeoutSeconds must be at least 0", errConfig)
+	}
+
+	if c.Readiness.CacheSeconds == 0 {
+		c.Readiness.CacheSeconds = defaultReadinessCacheSeconds
+	}
+
+	if c.Readiness.TimeoutSeconds == 0 {
+		c.Readiness.TimeoutSeconds = defaultReadinessTimeoutSeconds
+	}
+
+	err = c.Storage.validate()
+	if err != nil {
+		return err
+	}
+
+	err = c.Bans.validate()
+	if err != nil {
+		return err
+	}
+
+	err = c.validateIdempotency()
+	if err != nil {
+		return err
+	}
+
+	for _, cidrs := range [][]string{c.BlockedCIDRs, c.AllowedCIDRs, c.TrustedProxies} {
+		_, err = ParseCIDRs(cidrs)
+		if err != nil {
+			return err
+		}
+	}
+
 	seenIDs := map[string]struct{}{}
 
 	for _, site := range c.Sites {
@@ -117,17 +361,86 @@ func (c *Config) validate() error {
 			return fmt.Errorf("%w: empty site token", errConfig)
 		}
 
+		if site.RetentionDays < 0 {
+			return fmt.Errorf("%w: negative retentionDays for site %q", errConfig, site.ID)
+		}
+
 		if len(site.AllowedOrigins) == 0 {
 			return fmt.Errorf("%w: no allowed origins for site %q", errConfig, site.ID)
 		}
 
-		for _, form := range site.Forms {
-			err := form.validate()
+		for _, cidrs := range [][]string{site.BlockedCIDRs, site.AllowedCIDRs} {
+			_, err = ParseCIDRs(cidrs)
+			if err != nil {
+				return err
+			}
+		}
+
+		for i := range site.Forms {
+			form := &site.Forms[i]
+
+			err = form.validate()
 			if err != nil {
 				return err
 			}
+
+			if form.Captcha != nil && site.CaptchaSecret == "" {
+				return fmt.Errorf("%w: form %q uses CAPTCHA but site %q has no captchaSecret", errConfig, form.ID, site.ID)
+			}
+
+			if form.TimeTrap != nil && site.TimeTrapSecret == "" {
+				return fmt.Errorf("%w: form %q uses time trap but site %q has no timeTrapSecret", errConfig, form.ID, site.ID)
+			}
+
+			if form.ProofOfWork != nil && site.PowSecret == "" {
+				return fmt.Errorf("%w: form %q uses proof of work but site %q has no powSecret", errConfig, form.ID, site.ID)
+			}
+
+			if form.Store && c.Storage.Type == "" {
+				return fmt.Errorf(
+					"%w: form %q of site %q is stored but storage is not configured",
+					errConfig,
+					form.ID,
+					site.ID,
+				)
+			}
 		}
 	}
 
 	return nil
 }
+
+func (c *Config) validateIdempotency() error {
+	if c.Idempotency.WindowSeconds < 0 {
+		return fmt.Errorf("%w: idempotency windowSeconds must be at least 0", errConfig)
+	}
+
+	switch c.Idempotency.Backend {
+	case "":
+		c.Idempotency.Backend = IdempotencyMemory
+	case IdempotencyMemory:
+	case IdempotencyStorage:
+		if c.Storage.Type == "" {
+			return fmt.Errorf("%w: idempotency backend is storage but storage is not configured", errConfig)
+		}
+	default:
+		return fmt.Errorf("%w: unknown idempotency backend %q", errConfig, c.Idempotency.Backend)
+	}
+
+	return nil
+}
+
+func (s *Storage) validate() error {
+	switch s.Type {
+	case "":
+		return nil
+	case StorageSQLite:
+		if s.Path == "" {
+			return fmt.Errorf("%w: empty storage path", errConfig)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown storage type %q", errConfig, s.Type)
+	}
+}
@@ -19,3 +19,7 @@ const (
 	SiteTokenHeader = "X-Bifrost-Token"      // #nosec G101 -- False positive
 	FormTokenHeader = "X-Bifrost-Form-Token" // #nosec G101 -- False positive
 )
+
+// PowHeader is the name of the HTTP header field that may contain
+// the proof-of-work solution.
+const PowHeader = "X-Bifrost-Pow"
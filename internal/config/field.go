@@ -0,0 +1,341 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// Layouts of the date and datetime field values. The datetime values without
+// an offset are in the time zone of the field.
+const (
+	DateLayout          = time.DateOnly
+	DateTimeLocalLayout = "2006-01-02T15:04"
+)
+
+// Units of the length limits of the text fields.
+const (
+	// LengthCharacters counts the Unicode code points.
+	LengthCharacters = "characters"
+
+	// LengthGraphemes counts the user-perceived characters, so that, for
+	// example, an emoji with a skin tone modifier is a single character.
+	LengthGraphemes = "graphemes"
+
+	// LengthBytes counts the bytes of the UTF-8 encoding.
+	LengthBytes = "bytes"
+)
+
+// Unicode normalization forms of the text fields.
+const (
+	NormalizationNFC  = "NFC"
+	NormalizationNFKC = "NFKC"
+	NormalizationNone = "none"
+)
+
+var errDateBound = errors.New("invalid date bound")
+
+// relativeDate matches the relative date bounds, for example "today",
+// "today+7d", or "now-1y2m".
+var relativeDate = regexp.MustCompile(`^(today|now)((?:[+-]\d+[hdwmy])*)$`)
+
+var relativeOffset = regexp.MustCompile(`([+-])(\d+)([hdwmy])`)
+
+// Regexp returns the compiled pattern of the field or nil if the field has
+// no pattern.
+func (f *FormField) Regexp() *regexp.Regexp {
+	return f.pattern
+}
+
+// Location returns the time zone of the field.
+func (f *FormField) Location() *time.Location {
+	if f.location == nil {
+		return time.UTC
+	}
+
+	return f.location
+}
+
+// DateBounds returns the bounds of a date or datetime field at the given
+// time. The zero time is returned for the bounds that are not set.
+func (f *FormField) DateBounds(now time.Time) (time.Time, time.Time, error) {
+	minDate, err := ParseDateBound(f.MinDate, now, f.Location())
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	maxDate, err := ParseDateBound(f.MaxDate, now, f.Location())
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	return minDate, maxDate, nil
+}
+
+// LocalizedName returns the display name of the field in the language. It
+// falls back to DisplayName and then to the name of the field.
+func (f *FormField) LocalizedName(name, lang string) string {
+	if displayName, ok := f.DisplayNames[lang]; ok {
+		return displayName
+	}
+
+	if f.DisplayName != "" {
+		return f.DisplayName
+	}
+
+	return name
+}
+
+// IsNumber reports whether the bounds of the field bound the value instead of
+// the length or the number of the elements.
+func (f *FormField) IsNumber() bool {
+	return f.Type == FormFieldInt || f.Type == FormFieldNumber || f.Type == FormFieldDecimal
+}
+
+// MinInt returns the min of a field that is not a number field, or zero if
+// the min is not set.
+func (f *FormField) MinInt() int {
+	if f.Min == nil {
+		return 0
+	}
+
+	return int(*f.Min)
+}
+
+// MaxInt returns the max of a field that is not a number field and whether it
+// is set.
+func (f *FormField) MaxInt() (int, bool) {
+	if f.Max == nil {
+		return 0, false
+	}
+
+	return int(*f.Max), true
+}
+
+// IsText reports whether the values of the field are text that is sanitized
+// before it is validated.
+func (f *FormField) IsText() bool {
+	switch f.Type { //nolint:exhaustive // the rest are not text
+	case FormFieldString, FormFieldURL, FormFieldPhone, FormFieldDate, FormFieldDateTime, FormFieldDecimal:
+		return true
+	default:
+		return false
+	}
+}
+
+// ParseDateBound parses a date bound. The bound is either an absolute date or
+// datetime, or relative to "today" or "now" with optional offsets in hours
+// (h), days (d), weeks (w), months (m), or years (y), for example
+// "today+7d". "today" is the start of the current day in the location.
+// An empty bound returns the zero time.
+func ParseDateBound(s string, now time.Time, loc *time.Location) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	match := relativeDate.FindStringSubmatch(s)
+	if match == nil {
+		t, err := ParseDateTime(s, loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: %q", errDateBound, s)
+		}
+
+		return t, nil
+	}
+
+	t := now.In(loc)
+	if match[1] == "today" {
+		t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+
+	for _, offset := range relativeOffset.FindAllStringSubmatch(match[2], -1) {
+		n, err := strconv.Atoi(offset[2])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: %q", errDateBound, s)
+		}
+
+		if offset[1] == "-" {
+			n = -n
+		}
+
+		switch offset[3] {
+		case "h":
+			t = t.Add(time.Duration(n) * time.Hour)
+		case "d":
+			t = t.AddDate(0, 0, n)
+		case "w":
+			t = t.AddDate(0, 0, 7*n) //nolint:mnd // days in a week
+		case "m":
+			t = t.AddDate(0, n, 0)
+		case "y":
+			t = t.AddDate(n, 0, 0)
+		}
+	}
+
+	return t, nil
+}
+
+// ParseDateTime parses a date or datetime value. The values without an offset
+// are in the location.
+func ParseDateTime(s string, loc *time.Location) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err == nil {
+		return t, nil
+	}
+
+	for _, layout := range []string{time.DateTime, "2006-01-02T15:04:05", DateTimeLocalLayout, DateLayout} {
+		t, err = time.ParseInLocation(layout, s, loc)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("failed to parse date %q: %w", s, err)
+}
+
+// validateBounds validates the bounds and the precision of the field. The
+// bounds of the fields other than the number fields are lengths or counts, so
+// they must be whole numbers that are at least zero, and their max cannot be
+// zero.
+func (f *FormField) validateBounds(name string) error {
+	if f.Min != nil && f.Max != nil && *f.Max < *f.Min {
+		return fmt.Errorf("%w: max of field %q must be greater than the min", errConfig, name)
+	}
+
+	// Earlier, a zero max of the text, list, and objects fields meant that
+	// the field has no max. As the zero max now allows only the empty
+	// values, it is rejected so that the old configs do not change meaning
+	// silently.
+	if !f.IsNumber() && f.Max != nil && *f.Max == 0 {
+		return fmt.Errorf(
+			"%w: max of field %q is 0; max 0 no longer means no limit, remove max to leave the field unbounded",
+			errConfig,
+			name,
+		)
+	}
+
+	for _, bound := range []*float64{f.Min, f.Max} {
+		if bound == nil {
+			continue
+		}
+
+		if !f.IsNumber() && *bound < 0 {
+			return fmt.Errorf("%w: bounds of field %q must be at least zero", errConfig, name)
+		}
+
+		if f.Type != FormFieldNumber && f.Type != FormFieldDecimal && *bound != math.Trunc(*bound) {
+			return fmt.Errorf("%w: bounds of field %q must be whole numbers", errConfig, name)
+		}
+	}
+
+	if f.Precision != nil {
+		if f.Type != FormFieldNumber && f.Type != FormFieldDecimal {
+			return fmt.Errorf("%w: field %q of type %s cannot have a precision", errConfig, name, f.Type)
+		}
+
+		if *f.Precision < 0 {
+			return fmt.Errorf("%w: precision of field %q must be at least zero", errConfig, name)
+		}
+	}
+
+	return nil
+}
+
+// validateText validates the length unit and the normalization form of
+// the field and sets their defaults.
+func (f *FormField) validateText(name string) error {
+	if !f.IsText() {
+		if f.LengthUnit != "" || f.Normalization != "" || f.KeepWhitespace || f.KeepControlChars {
+			return fmt.Errorf("%w: field %q of type %s is not a text field", errConfig, name, f.Type)
+		}
+
+		return nil
+	}
+
+	switch f.LengthUnit {
+	case "":
+		f.LengthUnit = LengthCharacters
+	case LengthCharacters, LengthGraphemes, LengthBytes:
+	default:
+		return fmt.Errorf("%w: invalid length unit %q for field %q", errConfig, f.LengthUnit, name)
+	}
+
+	switch f.Normalization {
+	case "":
+		f.Normalization = NormalizationNFC
+	case NormalizationNFC, NormalizationNFKC, NormalizationNone:
+	default:
+		return fmt.Errorf("%w: invalid normalization %q for field %q", errConfig, f.Normalization, name)
+	}
+
+	return nil
+}
+
+// validateFormat validates the pattern, the default country, and the date
+// bounds of the field and prepares them for use.
+func (f *FormField) validateFormat(name string) error {
+	if f.Pattern != "" {
+		if f.Type != FormFieldString && f.Type != FormFieldURL && f.Type != FormFieldPhone {
+			return fmt.Errorf("%w: field %q of type %s cannot have a pattern", errConfig, name, f.Type)
+		}
+
+		pattern, err := regexp.Compile(f.Pattern)
+		if err != nil {
+			return fmt.Errorf("%w: invalid pattern for field %q: %w", errConfig, name, err)
+		}
+
+		f.pattern = pattern
+	}
+
+	if f.DefaultCountry != "" {
+		f.DefaultCountry = strings.ToUpper(f.DefaultCountry)
+
+		if phonenumbers.GetCountryCodeForRegion(f.DefaultCountry) == 0 {
+			return fmt.Errorf("%w: unknown default country %q for field %q", errConfig, f.DefaultCountry, name)
+		}
+	}
+
+	if f.TimeZone != "" {
+		loc, err := time.LoadLocation(f.TimeZone)
+		if err != nil {
+			return fmt.Errorf("%w: invalid time zone for field %q: %w", errConfig, name, err)
+		}
+
+		f.location = loc
+	}
+
+	if f.MinDate != "" || f.MaxDate != "" {
+		if f.Type != FormFieldDate && f.Type != FormFieldDateTime {
+			return fmt.Errorf("%w: field %q of type %s cannot have date bounds", errConfig, name, f.Type)
+		}
+
+		for _, bound := range []string{f.MinDate, f.MaxDate} {
+			_, err := ParseDateBound(bound, time.Now(), f.Location())
+			if err != nil {
+				return fmt.Errorf("%w: field %q: %w", errConfig, name, err)
+			}
+		}
+	}
+
+	return nil
+}
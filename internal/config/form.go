@@ -15,11 +15,18 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/visiosto/bifrost/internal/i18n"
 )
 
 // Form body content types.
@@ -27,12 +34,48 @@ const (
 	FormContentTypeJSON FormContentType = iota
 )
 
+// CAPTCHA providers.
+const (
+	CaptchaTurnstile = "turnstile"
+	CaptchaHCaptcha  = "hcaptcha"
+	CaptchaReCaptcha = "recaptcha"
+)
+
+// Actions for the submissions that fail the time trap.
+const (
+	TimeTrapFake   = "fake"
+	TimeTrapReject = "reject"
+)
+
+// Default values for the time trap config.
+const (
+	defaultTimeTrapField  = "bifrost-timestamp"
+	defaultTimeTrapMinAge = 3
+	defaultTimeTrapMaxAge = 24 * 60 * 60
+)
+
+// Default values for the proof-of-work config.
+const (
+	defaultPowField      = "bifrost-pow"
+	defaultPowDifficulty = 16
+	defaultPowMaxAge     = 5 * 60
+	maxPowDifficulty     = 32
+)
+
 // Form field types.
 const (
 	FormFieldBool FormFieldType = iota
 	FormFieldInt
 	FormFieldString
 	FormFieldObjects
+	FormFieldEnum
+	FormFieldEnumList
+	FormFieldURL
+	FormFieldPhone
+	FormFieldDate
+	FormFieldDateTime
+	FormFieldNumber
+	FormFieldDecimal
 )
 
 var (
@@ -46,6 +89,11 @@ type FormContentType int
 // FormFieldType is the type of a form field.
 type FormFieldType int //nolint:recvcheck // no need to have pointer receiver for all functions
 
+// FormFieldShape is the shape of the objects in an objects field. A value in
+// the shape can also be given as only the type, for example "string", in
+// which case the key is required in the objects.
+type FormFieldShape map[string]FormField
+
 // Form is the config of a form in a site.
 type Form struct {
 	ID                  string               `json:"id"`
@@ -55,29 +103,226 @@ type Form struct {
 	SESNotifiers        []*SESNotifier       `json:"ses"`
 	ContentType         FormContentType      `json:"contentType"`
 	AccessControlMaxAge int                  `json:"accessControlMaxAge"`
+
+	// Captcha is the CAPTCHA verification config of the form. The CAPTCHA is
+	// not verified if this is nil.
+	Captcha *Captcha `json:"captcha"`
+
+	// TimeTrap is the config for requiring a signed form timestamp in
+	// the submissions. The timestamp is not required if this is nil.
+	TimeTrap *TimeTrap `json:"timeTrap"`
+
+	// ProofOfWork is the config for requiring a solved proof-of-work
+	// challenge in the submissions. The challenge is not required if this is
+	// nil.
+	ProofOfWork *ProofOfWork `json:"proofOfWork"`
+
+	// Spam is the config for scoring the content of the submissions. The
+	// content is not scored if this is nil.
+	Spam *SpamFilter `json:"spam"`
+
+	// Rules are the cross-field validation rules of the form.
+	Rules Rules `json:"rules"`
+
+	// PublishSchema controls whether the JSON Schema of the payload of
+	// the form is served at the schema endpoint of the form.
+	PublishSchema bool `json:"publishSchema"`
+
+	// Lang is the language of the responses of the form if the request does
+	// not accept any of the languages that have messages. Defaults to
+	// [i18n.DefaultLang].
+	Lang string `json:"lang"`
+
+	// Messages override the messages of the responses by language and
+	// message code. The message codes are the validation error codes and
+	// the codes in package i18n, and "{field}" in the messages is replaced
+	// with the display name of the field.
+	Messages i18n.Messages `json:"messages"`
+
+	// RetentionDays overrides the retention period of the stored submissions
+	// of the site for this form.
+	RetentionDays int `json:"retentionDays"`
+
+	// Store controls whether the accepted submissions of the form are saved to
+	// the storage.
+	Store bool `json:"store"`
 }
 
 // FormField is the configuration for a single form field.
 type FormField struct {
 	// Shape is the shape of the objects in the array in the field if the type
-	// of the field [FormFieldObjects]. The keys are the keys of the objects and
-	// the values are the types. There is no further validation for the values
-	// for now, and further "objects" are not permitted.
-	//
-	// TODO: Add validation to make sure that:
-	//   1) objects have this,
-	//   2) others do not have this
-	//   3) objects include the template for printing the value row
-	Shape       map[string]FormFieldType `json:"shape"`
-	DisplayName string                   `json:"displayName"`
+	// of the field is [FormFieldObjects]. The keys are the keys of the objects
+	// and the values are the definitions of the values. Only the objects
+	// fields have a shape, and they must have one.
+	Shape       FormFieldShape `json:"shape"`
+	DisplayName string         `json:"displayName"`
+
+	// DisplayNames are the display names of the field by language. They are
+	// used in the responses, and DisplayName is used for the languages that
+	// are not listed.
+	DisplayNames map[string]string `json:"displayNames"`
 
 	// DisplayTemplate is a text template that is parsed and executed to display
-	// each element of objects.
+	// each element of objects. It is required for the objects fields of
+	// the form but not for the objects nested in the shapes.
 	DisplayTemplate string        `json:"displayTemplate"`
 	Type            FormFieldType `json:"type"`
-	Min             int           `json:"min"`
-	Max             int           `json:"max"`
-	Required        bool          `json:"required"`
+
+	// Min and Max are the inclusive bounds of the field. For the number
+	// fields, they bound the value, and for the text fields, they bound
+	// the length of the value. The bounds that are nil are not checked. Zero
+	// max is rejected for the fields other than the number fields, as it
+	// used to mean that the length is not limited.
+	Min *float64 `json:"min"`
+	Max *float64 `json:"max"`
+
+	// Precision is the maximum number of decimal places in the values of
+	// a number or decimal field. The precision is not limited if this is nil.
+	Precision *int `json:"precision"`
+	Required  bool `json:"required"`
+
+	// Options are the allowed values of the field if the type of the field is
+	// [FormFieldEnum] or [FormFieldEnumList]. For [FormFieldEnumList], Min and
+	// Max limit the number of the selected options, and for [FormFieldObjects]
+	// they limit the number of the objects.
+	Options []FormFieldOption `json:"options"`
+
+	// Pattern is a regular expression that the value of a string, URL, or
+	// phone field must match. It is compiled when the config is loaded.
+	Pattern string `json:"pattern"`
+
+	// DefaultCountry is the ISO 3166-1 alpha-2 code of the country that is
+	// assumed for the numbers of a phone field that have no country code.
+	// The phone numbers are normalized to the E.164 format.
+	DefaultCountry string `json:"defaultCountry"`
+
+	// MinDate and MaxDate are the inclusive bounds of a date or datetime
+	// field. They are either absolute dates or relative to "today" or "now",
+	// for example "today+7d". See [ParseDateBound].
+	MinDate string `json:"minDate"`
+	MaxDate string `json:"maxDate"`
+
+	// TimeZone is the IANA time zone of the date bounds and the datetime
+	// values without an offset. Defaults to UTC.
+	TimeZone string `json:"timeZone"`
+
+	// LengthUnit is the unit in which Min and Max limit the length of
+	// the text fields. Defaults to [LengthCharacters].
+	LengthUnit string `json:"lengthUnit"`
+
+	// Normalization is the Unicode normalization form that is applied to
+	// the values of the text fields. Defaults to [NormalizationNFC].
+	Normalization string `json:"normalization"`
+
+	// KeepWhitespace disables trimming the surrounding whitespace from
+	// the values of the text fields.
+	KeepWhitespace bool `json:"keepWhitespace"`
+
+	// KeepControlChars disables stripping the control characters other than
+	// tabs and line breaks from the values of the text fields.
+	KeepControlChars bool `json:"keepControlChars"`
+
+	pattern  *regexp.Regexp
+	location *time.Location
+}
+
+// FormFieldOption is an allowed value of an enum field.
+type FormFieldOption struct {
+	// Value is the value of the option in the payload.
+	Value string `json:"value"`
+
+	// Labels are the display labels of the option by language. The value is
+	// displayed if there is no label for the language.
+	Labels map[string]string `json:"labels"`
+}
+
+// Option returns the option of the field with the given value.
+func (f *FormField) Option(value string) (*FormFieldOption, bool) {
+	for i := range f.Options {
+		if f.Options[i].Value == value {
+			return &f.Options[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// Label returns the display label of the option in the given language.
+func (o *FormFieldOption) Label(lang string) string {
+	if label, ok := o.Labels[lang]; ok {
+		return label
+	}
+
+	return o.Value
+}
+
+// Captcha is the config for verifying a CAPTCHA token before the notifiers
+// are run.
+type Captcha struct {
+	// Provider is the CAPTCHA provider, either "turnstile", "hcaptcha", or
+	// "recaptcha".
+	Provider string `json:"provider"`
+
+	// TokenField is the payload field that contains the CAPTCHA token. It is
+	// removed from the payload before the validation. Defaults to the field
+	// name used by the widget of the provider.
+	TokenField string `json:"tokenField"`
+
+	// VerifyURL overrides the siteverify endpoint of the provider.
+	VerifyURL string `json:"verifyUrl"`
+
+	// MinScore is the minimum score required from reCAPTCHA v3. It is not
+	// checked if it is zero.
+	MinScore float64 `json:"minScore"`
+}
+
+// TimeTrap is the config for rejecting the submissions that are sent too
+// soon or too long after the form was rendered. The form fetches a signed
+// timestamp from the server when it is rendered and sends it back with
+// the submission.
+type TimeTrap struct {
+	// Field is the payload field that contains the signed timestamp. It is
+	// removed from the payload before the validation. Defaults to
+	// "bifrost-timestamp".
+	Field string `json:"field"`
+
+	// Action is what is done to the submissions that fail the check. It is
+	// either "fake" to respond with a fake success like for the honeypot or
+	// "reject" to respond with an error. Defaults to "fake".
+	Action string `json:"action"`
+
+	// MinAgeSeconds is the minimum age of the timestamp. Defaults to 3.
+	MinAgeSeconds int `json:"minAgeSeconds"`
+
+	// MaxAgeSeconds is the maximum age of the timestamp. Defaults to 24
+	// hours.
+	MaxAgeSeconds int `json:"maxAgeSeconds"`
+}
+
+// ProofOfWork is the config for the proof-of-work challenges of a form.
+// The solution is sent either in the "X-Bifrost-Pow" header or in
+// the payload field.
+type ProofOfWork struct {
+	// Field is the payload field that may contain the solution. It is
+	// removed from the payload before the validation. Defaults to
+	// "bifrost-pow".
+	Field string `json:"field"`
+
+	// Difficulty is the number of leading zero bits required from the hash
+	// of the solution. Defaults to 16.
+	Difficulty int `json:"difficulty"`
+
+	// MaxDifficulty is the upper limit for the difficulty when it is scaled
+	// with the request rate. Defaults to Difficulty plus 8.
+	MaxDifficulty int `json:"maxDifficulty"`
+
+	// MaxAgeSeconds is the time after which the challenges expire. Defaults
+	// to 5 minutes.
+	MaxAgeSeconds int `json:"maxAgeSeconds"`
+
+	// ScaleWithRate increases the difficulty of the issued challenges with
+	// the number of recent requests of the client to the site.
+	ScaleWithRate bool `json:"scaleWithRate"`
 }
 
 // SESNotifier is the config for an AWS SES form notifier.
@@ -87,7 +332,9 @@ type SESNotifier struct {
 	Lang string `json:"lang"`
 
 	// Subject is a text template that will be used as the subject of
-	// the notification email.
+	// the notification email. In the templates, ".payload" has the values of
+	// the enum fields replaced with their labels in Lang and ".values" has
+	// the submitted values.
 	Subject string `json:"subject"`
 
 	// Intro is a text template that will be used as an intro in
@@ -137,6 +384,44 @@ func (t *FormFieldType) UnmarshalJSON(data []byte) error {
 	return t.parse(s)
 }
 
+// UnmarshalJSON implements [encoding/json.Unmarshaler].
+func (s *FormFieldShape) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal form field shape: %w", err)
+	}
+
+	shape := make(FormFieldShape, len(raw))
+
+	for key, value := range raw {
+		var field FormField
+
+		if len(value) > 0 && value[0] == '"' {
+			err = field.Type.UnmarshalJSON(value)
+			field.Required = true
+		} else {
+			// The fields are decoded as strictly as the rest of the config
+			// so that misspelled options are not silently ignored.
+			dec := json.NewDecoder(bytes.NewReader(value))
+			dec.DisallowUnknownFields()
+
+			err = dec.Decode(&field)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal value %q of form field shape: %w", key, err)
+		}
+
+		shape[key] = field
+	}
+
+	*s = shape
+
+	return nil
+}
+
 func (t FormFieldType) String() string {
 	switch t {
 	case FormFieldBool:
@@ -147,6 +432,22 @@ func (t FormFieldType) String() string {
 		return "string"
 	case FormFieldObjects:
 		return "objects"
+	case FormFieldEnum:
+		return "enum"
+	case FormFieldEnumList:
+		return "enumList"
+	case FormFieldURL:
+		return "url"
+	case FormFieldPhone:
+		return "phone"
+	case FormFieldDate:
+		return "date"
+	case FormFieldDateTime:
+		return "datetime"
+	case FormFieldNumber:
+		return "number"
+	case FormFieldDecimal:
+		return "decimal"
 	default:
 		return "invalid-type"
 	}
@@ -156,12 +457,28 @@ func (t *FormFieldType) parse(s string) error {
 	switch strings.ToLower(s) {
 	case "bool", "boolean":
 		*t = FormFieldBool
-	case "int", "number":
+	case "int", "integer":
 		*t = FormFieldInt
 	case "string":
 		*t = FormFieldString
 	case "objects":
 		*t = FormFieldObjects
+	case "enum":
+		*t = FormFieldEnum
+	case "enumlist":
+		*t = FormFieldEnumList
+	case "url":
+		*t = FormFieldURL
+	case "phone":
+		*t = FormFieldPhone
+	case "date":
+		*t = FormFieldDate
+	case "datetime":
+		*t = FormFieldDateTime
+	case "number", "float":
+		*t = FormFieldNumber
+	case "decimal":
+		*t = FormFieldDecimal
 	default:
 		return fmt.Errorf("%w: %s", errUnknownField, s)
 	}
@@ -175,6 +492,10 @@ func (f *Form) validate() error {
 		return fmt.Errorf("%w: empty form ID", errConfig)
 	}
 
+	if f.RetentionDays < 0 {
+		return fmt.Errorf("%w: retentionDays must be at least 0", errConfig)
+	}
+
 	if f.AccessControlMaxAge < 0 {
 		return fmt.Errorf("%w: accessControlMaxAge must be at least 0", errConfig)
 	}
@@ -187,26 +508,284 @@ func (f *Form) validate() error {
 		f.Fields[f.HoneypotField] = FormField{Type: FormFieldString} //nolint:exhaustruct // use defaults
 	}
 
-	for _, field := range f.Fields {
-		if field.Min < 0 {
-			return fmt.Errorf("%w: min field length must be greater than zero", errConfig)
+	for name, field := range f.Fields {
+		err := field.validate(name, false)
+		if err != nil {
+			return err
+		}
+
+		f.Fields[name] = field
+	}
+
+	if f.Captcha != nil {
+		err := f.Captcha.validate()
+		if err != nil {
+			return err
+		}
+
+		if _, ok := f.Fields[f.Captcha.TokenField]; ok {
+			return fmt.Errorf("%w: CAPTCHA token field %q is specified as a form field", errConfig, f.Captcha.TokenField)
+		}
+	}
+
+	if f.TimeTrap != nil {
+		err := f.TimeTrap.validate()
+		if err != nil {
+			return err
+		}
+
+		if _, ok := f.Fields[f.TimeTrap.Field]; ok {
+			return fmt.Errorf("%w: time trap field %q is specified as a form field", errConfig, f.TimeTrap.Field)
+		}
+
+		if f.Captcha != nil && f.Captcha.TokenField == f.TimeTrap.Field {
+			return fmt.Errorf("%w: time trap field %q is the CAPTCHA token field", errConfig, f.TimeTrap.Field)
+		}
+	}
+
+	if f.ProofOfWork != nil {
+		err := f.validateProofOfWork()
+		if err != nil {
+			return err
+		}
+	}
+
+	if f.Spam != nil {
+		err := f.validateSpam()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := f.validateRules()
+	if err != nil {
+		return err
+	}
+
+	err = f.validateMessages()
+	if err != nil {
+		return err
+	}
+
+	err = f.validateSMTPNotifiers(f.SESNotifiers)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (f *Form) validateProofOfWork() error {
+	p := f.ProofOfWork
+
+	if p.Field == "" {
+		p.Field = defaultPowField
+	}
+
+	if _, ok := f.Fields[p.Field]; ok {
+		return fmt.Errorf("%w: proof-of-work field %q is specified as a form field", errConfig, p.Field)
+	}
+
+	if (f.Captcha != nil && f.Captcha.TokenField == p.Field) || (f.TimeTrap != nil && f.TimeTrap.Field == p.Field) {
+		return fmt.Errorf("%w: proof-of-work field %q is used by another check", errConfig, p.Field)
+	}
+
+	if p.Difficulty == 0 {
+		p.Difficulty = defaultPowDifficulty
+	}
+
+	if p.MaxDifficulty == 0 {
+		p.MaxDifficulty = min(p.Difficulty+8, maxPowDifficulty) //nolint:mnd // eight more bits by default
+	}
+
+	if p.Difficulty < 1 || p.MaxDifficulty < p.Difficulty || p.MaxDifficulty > maxPowDifficulty {
+		return fmt.Errorf("%w: proof-of-work difficulty must be between 1 and %d bits", errConfig, maxPowDifficulty)
+	}
+
+	if p.MaxAgeSeconds < 0 {
+		return fmt.Errorf("%w: proof-of-work maxAgeSeconds must be at least 0", errConfig)
+	}
+
+	if p.MaxAgeSeconds == 0 {
+		p.MaxAgeSeconds = defaultPowMaxAge
+	}
+
+	return nil
+}
+
+func (f *Form) validateMessages() error {
+	if f.Lang == "" {
+		f.Lang = i18n.DefaultLang
+	}
+
+	f.Lang = strings.ToLower(f.Lang)
+
+	for lang, messages := range f.Messages {
+		if lang != strings.ToLower(lang) || strings.Contains(lang, "-") {
+			return fmt.Errorf("%w: messages language %q must be a lowercase primary language subtag", errConfig, lang)
 		}
 
-		if field.Max < field.Min {
-			return fmt.Errorf("%w: max field length must be greater then the min length", errConfig)
+		for code := range messages {
+			if !i18n.IsCode(code) {
+				return fmt.Errorf("%w: unknown message code %q for language %q", errConfig, code, lang)
+			}
 		}
 	}
 
-	err := f.validateSMTPNotifiers()
+	return nil
+}
+
+// validate validates the field and the values in its shape. The nested fields
+// are the values in the shapes.
+func (f *FormField) validate(name string, nested bool) error {
+	err := f.validateBounds(name)
+	if err != nil {
+		return err
+	}
+
+	err = f.validateShape(name, nested)
+	if err != nil {
+		return err
+	}
+
+	err = f.validateOptions(name)
 	if err != nil {
 		return err
 	}
 
+	err = f.validateFormat(name)
+	if err != nil {
+		return err
+	}
+
+	return f.validateText(name)
+}
+
+func (f *FormField) validateShape(name string, nested bool) error {
+	if f.Type != FormFieldObjects {
+		if len(f.Shape) > 0 {
+			return fmt.Errorf("%w: field %q of type %s cannot have a shape", errConfig, name, f.Type)
+		}
+
+		return nil
+	}
+
+	if len(f.Shape) == 0 {
+		return fmt.Errorf("%w: no shape for field %q", errConfig, name)
+	}
+
+	if !nested && f.DisplayTemplate == "" {
+		return fmt.Errorf("%w: no display template for field %q", errConfig, name)
+	}
+
+	if f.DisplayTemplate != "" {
+		_, err := texttemplate.New(name).Parse(f.DisplayTemplate)
+		if err != nil {
+			return fmt.Errorf("%w: invalid display template for field %q: %w", errConfig, name, err)
+		}
+	}
+
+	for key, value := range f.Shape {
+		err := value.validate(name+"."+key, true)
+		if err != nil {
+			return err
+		}
+
+		f.Shape[key] = value
+	}
+
+	return nil
+}
+
+func (f *FormField) validateOptions(name string) error {
+	if f.Type != FormFieldEnum && f.Type != FormFieldEnumList {
+		if len(f.Options) > 0 {
+			return fmt.Errorf("%w: field %q of type %s cannot have options", errConfig, name, f.Type)
+		}
+
+		return nil
+	}
+
+	if len(f.Options) == 0 {
+		return fmt.Errorf("%w: no options for field %q", errConfig, name)
+	}
+
+	seen := map[string]struct{}{}
+
+	for _, option := range f.Options {
+		if option.Value == "" {
+			return fmt.Errorf("%w: empty option value in field %q", errConfig, name)
+		}
+
+		if _, ok := seen[option.Value]; ok {
+			return fmt.Errorf("%w: duplicate option %q in field %q", errConfig, option.Value, name)
+		}
+
+		seen[option.Value] = struct{}{}
+	}
+
+	return nil
+}
+
+func (c *Captcha) validate() error {
+	var tokenField string
+
+	switch c.Provider {
+	case CaptchaTurnstile:
+		tokenField = "cf-turnstile-response"
+	case CaptchaHCaptcha:
+		tokenField = "h-captcha-response"
+	case CaptchaReCaptcha:
+		tokenField = "g-recaptcha-response"
+	default:
+		return fmt.Errorf("%w: unknown CAPTCHA provider %q", errConfig, c.Provider)
+	}
+
+	if c.TokenField == "" {
+		c.TokenField = tokenField
+	}
+
+	if c.MinScore < 0 || c.MinScore > 1 {
+		return fmt.Errorf("%w: CAPTCHA minScore must be between 0 and 1", errConfig)
+	}
+
+	return nil
+}
+
+func (t *TimeTrap) validate() error {
+	if t.Field == "" {
+		t.Field = defaultTimeTrapField
+	}
+
+	switch t.Action {
+	case "":
+		t.Action = TimeTrapFake
+	case TimeTrapFake, TimeTrapReject:
+	default:
+		return fmt.Errorf("%w: unknown time trap action %q", errConfig, t.Action)
+	}
+
+	if t.MinAgeSeconds < 0 || t.MaxAgeSeconds < 0 {
+		return fmt.Errorf("%w: time trap ages must be at least 0", errConfig)
+	}
+
+	if t.MinAgeSeconds == 0 {
+		t.MinAgeSeconds = defaultTimeTrapMinAge
+	}
+
+	if t.MaxAgeSeconds == 0 {
+		t.MaxAgeSeconds = defaultTimeTrapMaxAge
+	}
+
+	if t.MaxAgeSeconds <= t.MinAgeSeconds {
+		return fmt.Errorf("%w: time trap maxAgeSeconds must be greater than minAgeSeconds", errConfig)
+	}
+
 	return nil
 }
 
-func (f *Form) validateSMTPNotifiers() error {
-	for _, smtp := range f.SESNotifiers {
+func (f *Form) validateSMTPNotifiers(notifiers []*SESNotifier) error {
+	for _, smtp := range notifiers {
 		if smtp.From == "" {
 			return fmt.Errorf("%w: empty From address", errConfig)
 		}
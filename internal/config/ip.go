@@ -0,0 +1,102 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// Default values for the ban config.
+const (
+	defaultBanWindowSeconds   = 10 * 60
+	defaultBanDurationSeconds = 60 * 60
+)
+
+// MaxBanDurationSeconds is the maximum duration of the automatic and
+// the manual bans, one year. It also keeps the duration from overflowing
+// when it is converted to a [time.Duration].
+const MaxBanDurationSeconds = 365 * 24 * 60 * 60
+
+// Bans is the config for banning the IP addresses that repeatedly trigger
+// the honeypot or exceed the rate limit. The IP addresses can also be
+// banned manually through the admin API.
+type Bans struct {
+	// Threshold is the number of strikes within the window after which
+	// the IP address is banned. Automatic bans are disabled if this is zero.
+	Threshold int `json:"threshold"`
+
+	// WindowSeconds is the time window for counting the strikes. Defaults to
+	// 10 minutes.
+	WindowSeconds int `json:"windowSeconds"`
+
+	// DurationSeconds is the duration of the automatic bans. Defaults to
+	// 1 hour, and it may be at most a year.
+	DurationSeconds int `json:"durationSeconds"`
+}
+
+// ParseCIDRs parses the CIDRs of a block, allow, or trusted proxy list.
+// Single IP addresses are accepted as well.
+func ParseCIDRs(cidrs []string) ([]netip.Prefix, error) {
+	result := make([]netip.Prefix, 0, len(cidrs))
+
+	for _, s := range cidrs {
+		if !strings.Contains(s, "/") {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid IP address %q: %w", errConfig, s, err)
+			}
+
+			addr = addr.Unmap()
+			result = append(result, netip.PrefixFrom(addr, addr.BitLen()))
+
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid CIDR %q: %w", errConfig, s, err)
+		}
+
+		result = append(result, prefix.Masked())
+	}
+
+	return result, nil
+}
+
+func (b *Bans) validate() error {
+	if b.Threshold < 0 || b.WindowSeconds < 0 || b.DurationSeconds < 0 {
+		return fmt.Errorf("%w: bans threshold, windowSeconds, and durationSeconds must be at least 0", errConfig)
+	}
+
+	if b.DurationSeconds > MaxBanDurationSeconds || b.WindowSeconds > MaxBanDurationSeconds {
+		return fmt.Errorf(
+			"%w: bans windowSeconds and durationSeconds must be at most %d",
+			errConfig,
+			MaxBanDurationSeconds,
+		)
+	}
+
+	if b.WindowSeconds == 0 {
+		b.WindowSeconds = defaultBanWindowSeconds
+	}
+
+	if b.DurationSeconds == 0 {
+		b.DurationSeconds = defaultBanDurationSeconds
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Log output formats.
+const (
+	LogFormatText LogFormat = iota
+	LogFormatJSON
+)
+
+// Special log outputs. Other log outputs are treated as file paths.
+const (
+	LogOutputStdout = "stdout"
+	LogOutputStderr = "stderr"
+)
+
+var errUnknownLogFormat = errors.New("unknown log format")
+
+// LogFormat is the format of the log output.
+type LogFormat int //nolint:recvcheck // no need to have pointer receiver for all functions
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+func (f *LogFormat) UnmarshalText(data []byte) error {
+	switch strings.ToLower(string(data)) {
+	case "text":
+		*f = LogFormatText
+	case "json":
+		*f = LogFormatJSON
+	default:
+		return fmt.Errorf("%w: %s", errUnknownLogFormat, string(data))
+	}
+
+	return nil
+}
+
+func (f LogFormat) String() string {
+	switch f {
+	case LogFormatText:
+		return "text"
+	case LogFormatJSON:
+		return "json"
+	default:
+		return "invalid-format"
+	}
+}
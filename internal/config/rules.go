@@ -0,0 +1,198 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+)
+
+// Rules are the cross-field validation rules of a form. They are evaluated
+// after each field has been validated on its own.
+type Rules struct {
+	// RequiredIf are the fields that are required depending on the values of
+	// other fields.
+	RequiredIf []RequiredIf `json:"requiredIf"`
+
+	// EqualsField are the pairs of fields that must have the same value, for
+	// example an email address and its confirmation.
+	EqualsField []EqualsField `json:"equalsField"`
+
+	// OneOf are the groups of fields of which at least one must be set.
+	OneOf [][]string `json:"oneOf"`
+
+	// MutuallyExclusive are the groups of fields of which at most one may be
+	// set.
+	MutuallyExclusive [][]string `json:"mutuallyExclusive"`
+}
+
+// RequiredIf makes a field required if another field has the given value.
+type RequiredIf struct {
+	// Field is the field that is required.
+	Field string `json:"field"`
+
+	// If is the field that the requirement depends on.
+	If string `json:"if"`
+
+	// Equals is the value of the If field that makes Field required. For
+	// [FormFieldEnumList], the list must contain the value. If this is nil,
+	// Field is required whenever the If field is set.
+	Equals any `json:"equals"`
+}
+
+// EqualsField requires that two fields have the same value.
+type EqualsField struct {
+	Field string `json:"field"`
+	Other string `json:"other"`
+}
+
+func (f *Form) validateRules() error {
+	r := f.Rules
+
+	for _, rule := range r.RequiredIf {
+		err := f.checkRuleFields("requiredIf", rule.Field, rule.If)
+		if err != nil {
+			return err
+		}
+
+		if rule.Field == rule.If {
+			return fmt.Errorf("%w: requiredIf rule for field %q depends on itself", errConfig, rule.Field)
+		}
+
+		if rule.Equals != nil {
+			err = f.checkRuleValue(rule.If, rule.Equals)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, rule := range r.EqualsField {
+		err := f.checkRuleFields("equalsField", rule.Field, rule.Other)
+		if err != nil {
+			return err
+		}
+
+		if rule.Field == rule.Other {
+			return fmt.Errorf("%w: equalsField rule compares field %q to itself", errConfig, rule.Field)
+		}
+
+		if f.Fields[rule.Field].Type != f.Fields[rule.Other].Type {
+			return fmt.Errorf(
+				"%w: equalsField rule compares fields %q and %q of different types",
+				errConfig,
+				rule.Field,
+				rule.Other,
+			)
+		}
+	}
+
+	for _, group := range r.OneOf {
+		err := f.checkRuleGroup("oneOf", group)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, group := range r.MutuallyExclusive {
+		err := f.checkRuleGroup("mutuallyExclusive", group)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *Form) checkRuleFields(kind string, names ...string) error {
+	for _, name := range names {
+		if name == "" {
+			return fmt.Errorf("%w: empty field in %s rule", errConfig, kind)
+		}
+
+		if _, ok := f.Fields[name]; !ok {
+			return fmt.Errorf("%w: unknown field %q in %s rule", errConfig, name, kind)
+		}
+
+		if name == f.HoneypotField {
+			return fmt.Errorf("%w: honeypot field %q in %s rule", errConfig, name, kind)
+		}
+	}
+
+	return nil
+}
+
+func (f *Form) checkRuleGroup(kind string, group []string) error {
+	if len(group) < 2 { //nolint:mnd // a group needs at least two fields
+		return fmt.Errorf("%w: %s rule needs at least two fields", errConfig, kind)
+	}
+
+	seen := map[string]struct{}{}
+
+	for _, name := range group {
+		if _, ok := seen[name]; ok {
+			return fmt.Errorf("%w: duplicate field %q in %s rule", errConfig, name, kind)
+		}
+
+		seen[name] = struct{}{}
+	}
+
+	return f.checkRuleFields(kind, group...)
+}
+
+// checkRuleValue checks that the value of a requiredIf rule can be a value of
+// the field.
+func (f *Form) checkRuleValue(name string, value any) error {
+	field := f.Fields[name]
+
+	var ok bool
+
+	switch field.Type {
+	case FormFieldBool:
+		_, ok = value.(bool)
+	case FormFieldInt, FormFieldNumber:
+		_, ok = value.(float64)
+	case FormFieldDecimal:
+		_, isString := value.(string)
+		_, isNumber := value.(float64)
+		ok = isString || isNumber
+	case FormFieldString, FormFieldURL, FormFieldPhone, FormFieldDate, FormFieldDateTime:
+		_, ok = value.(string)
+	case FormFieldEnum, FormFieldEnumList:
+		var s string
+
+		s, ok = value.(string)
+		if ok {
+			if _, ok = field.Option(s); !ok {
+				return fmt.Errorf("%w: requiredIf value %q is not an option of field %q", errConfig, s, name)
+			}
+		}
+	case FormFieldObjects:
+		return fmt.Errorf("%w: requiredIf rule cannot compare the value of objects field %q", errConfig, name)
+	default:
+		return fmt.Errorf("%w: invalid type of field %q", errConfig, name)
+	}
+
+	if !ok {
+		return fmt.Errorf(
+			"%w: requiredIf value %v does not match the type %s of field %q",
+			errConfig,
+			value,
+			field.Type,
+			name,
+		)
+	}
+
+	return nil
+}
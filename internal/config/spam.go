@@ -0,0 +1,289 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// Actions for the submissions that are scored as spam.
+const (
+	SpamDrop       = "drop"
+	SpamTag        = "tag"
+	SpamQuarantine = "quarantine"
+)
+
+// Default values for the spam filter config.
+const (
+	defaultSpamThreshold       = 5
+	defaultSpamSubjectTag      = "[SPAM]"
+	defaultSpamLinkScore       = 1
+	defaultSpamKeywordScore    = 2.5
+	defaultSpamScriptMaxRatio  = 0.3
+	defaultSpamScriptScore     = 5
+	defaultSpamDisposableScore = 3
+	defaultSpamRepeatWindow    = 24 * 60 * 60
+	defaultSpamRepeatScore     = 5
+	defaultSpamAkismetScore    = 10
+	defaultAkismetBaseURL      = "https://rest.akismet.com"
+)
+
+// SpamFilter is the config for scoring the content of the submissions. Each
+// rule that is set adds to the score of the submission, and the submissions
+// that reach the threshold are handled according to the action.
+type SpamFilter struct {
+	// Action is what is done to the spam submissions. It is either "drop" to
+	// respond with a fake success without sending the notifications, "tag"
+	// to send the notifications with the subject tag, or "quarantine" to
+	// send the notifications using the quarantine notifiers. Defaults to
+	// "drop".
+	Action string `json:"action"`
+
+	// SubjectTag is prepended to the subject of the tagged notifications.
+	// Defaults to "[SPAM]".
+	SubjectTag string `json:"subjectTag"`
+
+	// Quarantine are the notifiers that are used for the spam submissions
+	// when the action is "quarantine".
+	Quarantine []*SESNotifier `json:"quarantine"`
+
+	Links            *SpamLinks            `json:"links"`
+	Keywords         *SpamKeywords         `json:"keywords"`
+	Scripts          *SpamScripts          `json:"scripts"`
+	DisposableEmails *SpamDisposableEmails `json:"disposableEmails"`
+	Repeats          *SpamRepeats          `json:"repeats"`
+	Akismet          *SpamAkismet          `json:"akismet"`
+
+	// Threshold is the score at which a submission is considered spam.
+	// Defaults to 5.
+	Threshold float64 `json:"threshold"`
+}
+
+// SpamLinks is the config for the rule that scores the links in
+// the submission.
+type SpamLinks struct {
+	// Max is the number of links that are allowed without adding to
+	// the score.
+	Max int `json:"max"`
+
+	// Score is added for each link over the maximum. Defaults to 1.
+	Score float64 `json:"score"`
+}
+
+// SpamKeywords is the config for the rule that scores the blocked keywords
+// and patterns in the submission.
+type SpamKeywords struct {
+	// Keywords are matched case-insensitively anywhere in the text fields.
+	Keywords []string `json:"keywords"`
+
+	// Patterns are regular expressions that are matched against the text
+	// fields.
+	Patterns []string `json:"patterns"`
+
+	// Score is added for each keyword and pattern that matches. Defaults
+	// to 2.5.
+	Score float64 `json:"score"`
+}
+
+// SpamScripts is the config for the rule that scores the Cyrillic and CJK
+// letters in the submission. It is meant for the forms that are in a language
+// written in the Latin script.
+type SpamScripts struct {
+	// MaxRatio is the share of the letters that may be Cyrillic or CJK
+	// without adding to the score. Defaults to 0.3.
+	MaxRatio float64 `json:"maxRatio"`
+
+	// Score is added if the ratio is exceeded. Defaults to 5.
+	Score float64 `json:"score"`
+}
+
+// SpamDisposableEmails is the config for the rule that scores the email
+// addresses from disposable email providers.
+type SpamDisposableEmails struct {
+	// Domains are checked in addition to the built-in list of disposable
+	// email domains.
+	Domains []string `json:"domains"`
+
+	// Score is added if an email address is from a disposable domain.
+	// Defaults to 3.
+	Score float64 `json:"score"`
+}
+
+// SpamRepeats is the config for the rule that scores the submissions that
+// are identical to a recent submission to the same form.
+type SpamRepeats struct {
+	// WindowSeconds is how long the submissions are remembered. Defaults to
+	// 24 hours.
+	WindowSeconds int `json:"windowSeconds"`
+
+	// Score is added if the submission is a repeat. Defaults to 5.
+	Score float64 `json:"score"`
+}
+
+// SpamAkismet is the config for checking the submissions with the Akismet
+// comment-check API.
+type SpamAkismet struct {
+	// APIKey is the Akismet API key.
+	APIKey string `json:"apiKey"`
+
+	// Blog is the URL of the site that is sent as the "blog" parameter.
+	Blog string `json:"blog"`
+
+	// BaseURL overrides the base URL of the Akismet API. Defaults to
+	// "https://rest.akismet.com".
+	BaseURL string `json:"baseUrl"`
+
+	// Lang is the language of the form that is sent as the "blog_lang"
+	// parameter.
+	Lang string `json:"lang"`
+
+	// AuthorField is the form field that contains the name of the sender.
+	AuthorField string `json:"authorField"`
+
+	// EmailField is the form field that contains the email address of
+	// the sender.
+	EmailField string `json:"emailField"`
+
+	// ContentFields are the form fields that are sent as the content. All of
+	// the text in the submission is sent if this is empty.
+	ContentFields []string `json:"contentFields"`
+
+	// Score is added if Akismet considers the submission spam. Defaults to
+	// 10.
+	Score float64 `json:"score"`
+}
+
+func (f *Form) validateSpam() error {
+	s := f.Spam
+
+	switch s.Action {
+	case "":
+		s.Action = SpamDrop
+	case SpamDrop, SpamTag:
+	case SpamQuarantine:
+		if len(s.Quarantine) == 0 {
+			return fmt.Errorf("%w: spam action of form %q is quarantine but there are no quarantine notifiers", errConfig, f.ID)
+		}
+	default:
+		return fmt.Errorf("%w: unknown spam action %q", errConfig, s.Action)
+	}
+
+	if s.SubjectTag == "" {
+		s.SubjectTag = defaultSpamSubjectTag
+	}
+
+	if s.Threshold < 0 {
+		return fmt.Errorf("%w: spam threshold must be at least 0", errConfig)
+	}
+
+	if s.Threshold == 0 {
+		s.Threshold = defaultSpamThreshold
+	}
+
+	if s.Links != nil {
+		if s.Links.Max < 0 {
+			return fmt.Errorf("%w: spam links max must be at least 0", errConfig)
+		}
+
+		s.Links.Score = defaultScore(s.Links.Score, defaultSpamLinkScore)
+	}
+
+	if s.Keywords != nil {
+		for _, pattern := range s.Keywords.Patterns {
+			_, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("%w: invalid spam pattern %q: %w", errConfig, pattern, err)
+			}
+		}
+
+		s.Keywords.Score = defaultScore(s.Keywords.Score, defaultSpamKeywordScore)
+	}
+
+	if s.Scripts != nil {
+		if s.Scripts.MaxRatio < 0 || s.Scripts.MaxRatio > 1 {
+			return fmt.Errorf("%w: spam scripts maxRatio must be between 0 and 1", errConfig)
+		}
+
+		if s.Scripts.MaxRatio == 0 {
+			s.Scripts.MaxRatio = defaultSpamScriptMaxRatio
+		}
+
+		s.Scripts.Score = defaultScore(s.Scripts.Score, defaultSpamScriptScore)
+	}
+
+	if s.DisposableEmails != nil {
+		s.DisposableEmails.Score = defaultScore(s.DisposableEmails.Score, defaultSpamDisposableScore)
+	}
+
+	if s.Repeats != nil {
+		if s.Repeats.WindowSeconds < 0 {
+			return fmt.Errorf("%w: spam repeats windowSeconds must be at least 0", errConfig)
+		}
+
+		if s.Repeats.WindowSeconds == 0 {
+			s.Repeats.WindowSeconds = defaultSpamRepeatWindow
+		}
+
+		s.Repeats.Score = defaultScore(s.Repeats.Score, defaultSpamRepeatScore)
+	}
+
+	if s.Akismet != nil {
+		err := f.validateAkismet(s.Akismet)
+		if err != nil {
+			return err
+		}
+	}
+
+	return f.validateSMTPNotifiers(s.Quarantine)
+}
+
+func (f *Form) validateAkismet(a *SpamAkismet) error {
+	if a.APIKey == "" {
+		return fmt.Errorf("%w: empty Akismet API key", errConfig)
+	}
+
+	if a.Blog == "" {
+		return fmt.Errorf("%w: empty Akismet blog URL", errConfig)
+	}
+
+	if a.BaseURL == "" {
+		a.BaseURL = defaultAkismetBaseURL
+	}
+
+	fields := slices.Concat([]string{a.AuthorField, a.EmailField}, a.ContentFields)
+	for _, name := range fields {
+		if name == "" {
+			continue
+		}
+
+		if _, ok := f.Fields[name]; !ok {
+			return fmt.Errorf("%w: unknown field name %q in Akismet config of form %q", errConfig, name, f.ID)
+		}
+	}
+
+	a.Score = defaultScore(a.Score, defaultSpamAkismetScore)
+
+	return nil
+}
+
+func defaultScore(score, def float64) float64 {
+	if score == 0 {
+		return def
+	}
+
+	return score
+}
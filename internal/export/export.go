@@ -0,0 +1,301 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package export writes the stored submissions of a form in the formats that
+// are suitable for handing them over to the clients.
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/visiosto/bifrost/internal/config"
+	"github.com/visiosto/bifrost/internal/storage"
+)
+
+// Export formats.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// batchSize is the number of submissions read from the storage at a time.
+const batchSize = 500
+
+var errUnknownFormat = errors.New("unknown export format")
+
+// Exporter writes the submissions of a single form.
+type Exporter struct {
+	form    *config.Form
+	objs    map[string]*texttemplate.Template
+	columns []string
+}
+
+// New returns an Exporter for the given form. The columns are all of
+// the fields of the form except the honeypot field. They are in the field
+// order of the first SES notifier, followed by the rest of the fields in
+// alphabetical order.
+func New(form *config.Form) (*Exporter, error) {
+	objs := map[string]*texttemplate.Template{}
+
+	for name, field := range form.Fields {
+		if field.Type != config.FormFieldObjects {
+			continue
+		}
+
+		tmpl, err := texttemplate.New(name).Parse(field.DisplayTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse display template for field %q: %w", name, err)
+		}
+
+		objs[name] = tmpl
+	}
+
+	return &Exporter{form: form, objs: objs, columns: columns(form)}, nil
+}
+
+// Write streams the submissions that match the filter to w in the given
+// format.
+func (e *Exporter) Write(
+	ctx context.Context,
+	w io.Writer,
+	store storage.Store,
+	filter *storage.Filter,
+	format string,
+) error {
+	switch format {
+	case FormatCSV:
+		return e.writeCSV(ctx, w, store, filter)
+	case FormatNDJSON:
+		return e.writeNDJSON(ctx, w, store, filter)
+	default:
+		return fmt.Errorf("%w: %s", errUnknownFormat, format)
+	}
+}
+
+func (e *Exporter) writeCSV(ctx context.Context, w io.Writer, store storage.Store, filter *storage.Filter) error {
+	cw := csv.NewWriter(w)
+	header := []string{"ID", "Received"}
+
+	for _, name := range e.columns {
+		header = append(header, displayName(e.form, name))
+	}
+
+	err := cw.Write(header)
+	if err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	err = eachSubmission(ctx, store, filter, func(sub *storage.Submission) error {
+		record := []string{strconv.FormatInt(sub.ID, 10), sub.ReceivedAt.Format(time.RFC3339)}
+
+		for _, name := range e.columns {
+			value, err := e.format(name, sub.Payload[name])
+			if err != nil {
+				return fmt.Errorf("failed to format field %q of submission %d: %w", name, sub.ID, err)
+			}
+
+			record = append(record, escapeFormula(value))
+		}
+
+		err := cw.Write(record)
+		if err != nil {
+			return fmt.Errorf("failed to write CSV record: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+
+	err = cw.Error()
+	if err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+
+	return nil
+}
+
+func (e *Exporter) writeNDJSON(ctx context.Context, w io.Writer, store storage.Store, filter *storage.Filter) error {
+	enc := json.NewEncoder(w)
+
+	return eachSubmission(ctx, store, filter, func(sub *storage.Submission) error {
+		payload := make(map[string]any, len(e.columns))
+
+		for _, name := range e.columns {
+			if v, ok := sub.Payload[name]; ok {
+				payload[name] = v
+			}
+		}
+
+		err := enc.Encode(map[string]any{
+			"id":         sub.ID,
+			"receivedAt": sub.ReceivedAt,
+			"payload":    payload,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write submission %d: %w", sub.ID, err)
+		}
+
+		return nil
+	})
+}
+
+// format formats a single payload value for a CSV cell. The objects are
+// formatted with the display template of the field, one object per line, and
+// the selected options of enum lists are joined with commas.
+func (e *Exporter) format(name string, value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []any:
+		if e.form.Fields[name].Type == config.FormFieldEnumList {
+			values := make([]string, 0, len(v))
+			for _, x := range v {
+				values = append(values, fmt.Sprint(x))
+			}
+
+			return strings.Join(values, ", "), nil
+		}
+
+		tmpl, ok := e.objs[name]
+		if !ok {
+			return fmt.Sprint(v), nil
+		}
+
+		lines := make([]string, 0, len(v))
+
+		for _, obj := range v {
+			var buf bytes.Buffer
+
+			err := tmpl.Execute(&buf, obj)
+			if err != nil {
+				return "", fmt.Errorf("failed to execute display template: %w", err)
+			}
+
+			lines = append(lines, buf.String())
+		}
+
+		return strings.Join(lines, "\n"), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// eachSubmission calls f for each submission that matches the filter, reading
+// them from the storage in batches.
+func eachSubmission(
+	ctx context.Context,
+	store storage.Store,
+	filter *storage.Filter,
+	f func(sub *storage.Submission) error,
+) error {
+	batch := *filter
+	batch.Limit = batchSize
+	batch.Offset = 0
+
+	for {
+		subs, err := store.List(ctx, &batch)
+		if err != nil {
+			return fmt.Errorf("failed to list submissions: %w", err)
+		}
+
+		for _, sub := range subs {
+			err = f(sub)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(subs) < batchSize {
+			return nil
+		}
+
+		batch.Offset += batchSize
+	}
+}
+
+// columns returns the fields of the form in the order of the columns. All of
+// the fields except the honeypot are exported. The fields are ordered by
+// the field order of the first notifier, and the rest of the fields follow in
+// alphabetical order.
+func columns(form *config.Form) []string {
+	var order []string
+
+	if len(form.SESNotifiers) > 0 {
+		order = form.SESNotifiers[0].FieldOrder
+	}
+
+	result := make([]string, 0, len(form.Fields))
+
+	for _, name := range order {
+		if _, ok := form.Fields[name]; ok && name != form.HoneypotField {
+			result = append(result, name)
+		}
+	}
+
+	rest := make([]string, 0, len(form.Fields)-len(result))
+
+	for name := range form.Fields {
+		if name != form.HoneypotField && !slices.Contains(result, name) {
+			rest = append(rest, name)
+		}
+	}
+
+	slices.Sort(rest)
+
+	return append(result, rest...)
+}
+
+func displayName(form *config.Form, name string) string {
+	if field := form.Fields[name]; field.DisplayName != "" {
+		return field.DisplayName
+	}
+
+	return name
+}
+
+// escapeFormula prevents spreadsheet programs from interpreting the cell as
+// a formula. The numbers are kept as they are so that the negative numbers
+// and the phone numbers in the E.164 format are not changed.
+func escapeFormula(s string) string {
+	if s == "" || !strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return s
+	}
+
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return s
+	}
+
+	return "'" + s
+}
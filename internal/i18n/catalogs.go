@@ -0,0 +1,111 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+// catalogs are the built-in messages by language and message code.
+//
+//nolint:gochecknoglobals,lll // the catalogs are static data
+var catalogs = map[string]map[string]string{
+	"en": {
+		"invalid_json":       "The request is not valid JSON.",
+		"multiple_objects":   "The request must contain a single JSON object.",
+		"unknown_field":      "The form has no field {field}.",
+		"invalid_type":       "The field {field} has an invalid value.",
+		"required":           "The field {field} is required.",
+		"out_of_range":       "The value of the field {field} is not within the allowed limits.",
+		"invalid_object":     "The field {field} contains an invalid entry.",
+		"invalid_option":     "The field {field} contains an option that is not available.",
+		"invalid_pattern":    "The value of the field {field} is not in the expected format.",
+		"invalid_url":        "The field {field} must be a valid web address.",
+		"invalid_phone":      "The field {field} must be a valid phone number.",
+		"invalid_date":       "The field {field} must be a valid date.",
+		"invalid_number":     "The field {field} must be a valid number.",
+		"mismatch":           "The value of the field {field} does not match.",
+		"one_of":             "Fill in the field {field} or one of its alternatives.",
+		"mutually_exclusive": "The field {field} cannot be filled in together with the other fields.",
+		"captcha":            "The CAPTCHA verification failed. Please try again.",
+		"time_trap":          "The form was submitted too quickly or the page has expired. Please try again.",
+		"proof_of_work":      "The spam protection check failed. Please try again.",
+		"other":              "The submission is invalid.",
+		CodeAccepted:         "accepted",
+		CodeBadRequest:       "The request is invalid.",
+		CodeUnauthorized:     "The request is not authorized.",
+		CodeForbidden:        "The submission was rejected.",
+		CodeTooManyRequests:  "Too many submissions. Please try again later.",
+		CodeUnavailable:      "The service is temporarily unavailable. Please try again later.",
+		CodeInternalError:    "Something went wrong. Please try again later.",
+		CodeKeyReused:        "The Idempotency-Key was already used for a different submission.",
+	},
+	"fi": {
+		"invalid_json":       "Pyyntö ei ole kelvollista JSONia.",
+		"multiple_objects":   "Pyynnössä saa olla vain yksi JSON-olio.",
+		"unknown_field":      "Lomakkeella ei ole kenttää {field}.",
+		"invalid_type":       "Kentän {field} arvo on virheellinen.",
+		"required":           "Kenttä {field} on pakollinen.",
+		"out_of_range":       "Kentän {field} arvo ei ole sallituissa rajoissa.",
+		"invalid_object":     "Kentässä {field} on virheellinen rivi.",
+		"invalid_option":     "Kentässä {field} on valinta, joka ei ole käytettävissä.",
+		"invalid_pattern":    "Kentän {field} arvo ei ole oikeassa muodossa.",
+		"invalid_url":        "Kentän {field} arvon on oltava kelvollinen verkko-osoite.",
+		"invalid_phone":      "Kentän {field} arvon on oltava kelvollinen puhelinnumero.",
+		"invalid_date":       "Kentän {field} arvon on oltava kelvollinen päivämäärä.",
+		"invalid_number":     "Kentän {field} arvon on oltava kelvollinen luku.",
+		"mismatch":           "Kentän {field} arvo ei täsmää.",
+		"one_of":             "Täytä kenttä {field} tai jokin sen vaihtoehdoista.",
+		"mutually_exclusive": "Kenttää {field} ei voi täyttää yhdessä muiden kenttien kanssa.",
+		"captcha":            "CAPTCHA-tarkistus epäonnistui. Yritä uudelleen.",
+		"time_trap":          "Lomake lähetettiin liian nopeasti tai sivu on vanhentunut. Yritä uudelleen.",
+		"proof_of_work":      "Roskapostisuojauksen tarkistus epäonnistui. Yritä uudelleen.",
+		"other":              "Lähetys on virheellinen.",
+		CodeAccepted:         "vastaanotettu",
+		CodeBadRequest:       "Pyyntö on virheellinen.",
+		CodeUnauthorized:     "Pyyntöä ei ole valtuutettu.",
+		CodeForbidden:        "Lähetys hylättiin.",
+		CodeTooManyRequests:  "Liian monta lähetystä. Yritä myöhemmin uudelleen.",
+		CodeUnavailable:      "Palvelu ei ole tilapäisesti käytettävissä. Yritä myöhemmin uudelleen.",
+		CodeInternalError:    "Jokin meni vikaan. Yritä myöhemmin uudelleen.",
+		CodeKeyReused:        "Idempotency-Key on jo käytetty toiseen lähetykseen.",
+	},
+	"sv": {
+		"invalid_json":       "Begäran är inte giltig JSON.",
+		"multiple_objects":   "Begäran får bara innehålla ett JSON-objekt.",
+		"unknown_field":      "Formuläret har inget fält {field}.",
+		"invalid_type":       "Fältet {field} har ett ogiltigt värde.",
+		"required":           "Fältet {field} är obligatoriskt.",
+		"out_of_range":       "Värdet i fältet {field} är inte inom de tillåtna gränserna.",
+		"invalid_object":     "Fältet {field} innehåller en ogiltig rad.",
+		"invalid_option":     "Fältet {field} innehåller ett val som inte är tillgängligt.",
+		"invalid_pattern":    "Värdet i fältet {field} har fel format.",
+		"invalid_url":        "Fältet {field} måste vara en giltig webbadress.",
+		"invalid_phone":      "Fältet {field} måste vara ett giltigt telefonnummer.",
+		"invalid_date":       "Fältet {field} måste vara ett giltigt datum.",
+		"invalid_number":     "Fältet {field} måste vara ett giltigt tal.",
+		"mismatch":           "Värdet i fältet {field} stämmer inte överens.",
+		"one_of":             "Fyll i fältet {field} eller något av dess alternativ.",
+		"mutually_exclusive": "Fältet {field} kan inte fyllas i tillsammans med de andra fälten.",
+		"captcha":            "CAPTCHA-kontrollen misslyckades. Försök igen.",
+		"time_trap":          "Formuläret skickades för snabbt eller så har sidan gått ut. Försök igen.",
+		"proof_of_work":      "Kontrollen av skräppostskyddet misslyckades. Försök igen.",
+		"other":              "Inlämningen är ogiltig.",
+		CodeAccepted:         "mottaget",
+		CodeBadRequest:       "Begäran är ogiltig.",
+		CodeUnauthorized:     "Begäran är inte behörig.",
+		CodeForbidden:        "Inlämningen avvisades.",
+		CodeTooManyRequests:  "För många inlämningar. Försök igen senare.",
+		CodeUnavailable:      "Tjänsten är tillfälligt otillgänglig. Försök igen senare.",
+		CodeInternalError:    "Något gick fel. Försök igen senare.",
+		CodeKeyReused:        "Idempotency-Key har redan använts för en annan inlämning.",
+	},
+}
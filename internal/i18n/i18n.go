@@ -0,0 +1,138 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package i18n contains the message catalogs of the responses of the form
+// endpoints and the selection of the language of the responses.
+package i18n
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultLang is the language of the responses if neither the request nor
+// the form selects a language that has messages.
+const DefaultLang = "en"
+
+// Codes of the messages of the responses that are not validation errors.
+// The validation errors use their error codes as the message codes.
+const (
+	CodeAccepted        = "accepted"
+	CodeBadRequest      = "bad_request"
+	CodeUnauthorized    = "unauthorized"
+	CodeForbidden       = "forbidden"
+	CodeTooManyRequests = "too_many_requests"
+	CodeUnavailable     = "unavailable"
+	CodeInternalError   = "internal_error"
+	CodeKeyReused       = "key_reused"
+)
+
+// FieldPlaceholder is replaced with the display name of the field in
+// the messages.
+const FieldPlaceholder = "{field}"
+
+// Messages are message overrides by language and message code.
+type Messages map[string]map[string]string
+
+// IsCode reports whether the code is the code of a message in the built-in
+// catalogs.
+func IsCode(code string) bool {
+	_, ok := catalogs[DefaultLang][code]
+
+	return ok
+}
+
+// Negotiate returns the language of the response for the value of
+// the Accept-Language header. The languages are matched by their primary
+// subtags against the languages of the built-in catalogs and the overrides.
+// The fallback is returned if no language matches.
+func Negotiate(acceptLanguage, fallback string, overrides Messages) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+
+	var candidates []candidate
+
+	for part := range strings.SplitSeq(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+
+			q = parsed
+		}
+
+		if q <= 0 {
+			continue
+		}
+
+		primary, _, _ := strings.Cut(tag, "-")
+		candidates = append(candidates, candidate{lang: strings.ToLower(primary), q: q})
+	}
+
+	// The stable sort keeps the order of the header for the equal weights.
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	for _, c := range candidates {
+		if _, ok := catalogs[c.lang]; ok {
+			return c.lang
+		}
+
+		if _, ok := overrides[c.lang]; ok {
+			return c.lang
+		}
+	}
+
+	return fallback
+}
+
+// Message returns the message with the code in the language with
+// the display name of the field in place of [FieldPlaceholder]. The overrides
+// take precedence over the built-in catalogs, and the message in
+// [DefaultLang] is used if the language has no message for the code.
+func Message(lang, code, field string, overrides Messages) string {
+	msg, ok := overrides[lang][code]
+	if !ok {
+		msg, ok = catalogs[lang][code]
+	}
+
+	if !ok {
+		msg, ok = catalogs[DefaultLang][code]
+	}
+
+	if !ok {
+		return code
+	}
+
+	return strings.ReplaceAll(msg, FieldPlaceholder, field)
+}
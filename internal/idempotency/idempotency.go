@@ -0,0 +1,140 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package idempotency remembers the responses to the form submissions so
+// that the repeated requests get the original response without the form
+// being handled again.
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned by the backends when there is no response for
+	// the key.
+	ErrNotFound = errors.New("response not found")
+
+	// ErrMismatch is returned when the key is reused for a request with
+	// a different fingerprint.
+	ErrMismatch = errors.New("key reused for a different request")
+)
+
+// Response is a remembered response.
+type Response struct {
+	ContentType string `json:"contentType"`
+
+	// Fingerprint identifies the request that the response was for.
+	Fingerprint string `json:"fingerprint"`
+	Body        []byte `json:"body"`
+	Status      int    `json:"status"`
+}
+
+// Backend stores the remembered responses.
+type Backend interface {
+	// LoadResponse returns the response for the key. It returns
+	// [ErrNotFound] if there is no response or it has expired.
+	LoadResponse(ctx context.Context, key string) (*Response, error)
+
+	// StoreResponse stores the response for the key until it expires.
+	StoreResponse(ctx context.Context, key string, resp *Response, expires time.Time) error
+}
+
+// Deduper runs the requests once per key within the window. The concurrent
+// requests with the same key wait for the first one to finish.
+type Deduper struct {
+	backend  Backend
+	inflight map[string]chan struct{}
+	window   time.Duration
+	mu       sync.Mutex
+}
+
+// New returns a Deduper that remembers the responses in the backend for
+// the window.
+func New(backend Backend, window time.Duration) *Deduper {
+	return &Deduper{
+		backend:  backend,
+		inflight: map[string]chan struct{}{},
+		window:   window,
+		mu:       sync.Mutex{},
+	}
+}
+
+// Do returns the remembered response for the key, or runs run and remembers
+// its response. The returned bool is true if the response was remembered.
+// [ErrMismatch] is returned if the remembered response was for a request
+// with another fingerprint. Only the successful responses are remembered so
+// that the failed requests can be retried.
+func (d *Deduper) Do(ctx context.Context, key, fingerprint string, run func() *Response) (*Response, bool, error) {
+	var done chan struct{}
+
+	for done == nil {
+		resp, err := d.backend.LoadResponse(ctx, key)
+		if err == nil {
+			if resp.Fingerprint != fingerprint {
+				return nil, false, ErrMismatch
+			}
+
+			return resp, true, nil
+		}
+
+		if !errors.Is(err, ErrNotFound) {
+			return nil, false, fmt.Errorf("failed to load response: %w", err)
+		}
+
+		d.mu.Lock()
+
+		wait, ok := d.inflight[key]
+		if !ok {
+			done = make(chan struct{})
+			d.inflight[key] = done
+		}
+
+		d.mu.Unlock()
+
+		if ok {
+			select {
+			case <-wait:
+			case <-ctx.Done():
+				return nil, false, fmt.Errorf("failed to wait for the original request: %w", ctx.Err())
+			}
+		}
+	}
+
+	defer func() {
+		d.mu.Lock()
+		delete(d.inflight, key)
+		d.mu.Unlock()
+		close(done)
+	}()
+
+	resp := run()
+	resp.Fingerprint = fingerprint
+
+	if resp.Status >= http.StatusBadRequest {
+		return resp, false, nil
+	}
+
+	err := d.backend.StoreResponse(ctx, key, resp, time.Now().Add(d.window))
+	if err != nil {
+		return resp, false, fmt.Errorf("failed to store response: %w", err)
+	}
+
+	return resp, false, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const pruneInterval = time.Minute
+
+// Memory is a [Backend] that keeps the responses in memory.
+type Memory struct {
+	responses map[string]memoryEntry
+	lastPrune time.Time
+	mu        sync.Mutex
+}
+
+type memoryEntry struct {
+	expires time.Time
+	resp    *Response
+}
+
+// NewMemory returns a new Memory.
+func NewMemory() *Memory {
+	return &Memory{responses: map[string]memoryEntry{}, lastPrune: time.Time{}, mu: sync.Mutex{}}
+}
+
+// LoadResponse implements [Backend].
+func (m *Memory) LoadResponse(_ context.Context, key string) (*Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.responses[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, ErrNotFound
+	}
+
+	return entry.resp, nil
+}
+
+// StoreResponse implements [Backend].
+func (m *Memory) StoreResponse(_ context.Context, key string, resp *Response, expires time.Time) error {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastPrune) > pruneInterval {
+		for k, entry := range m.responses {
+			if now.After(entry.expires) {
+				delete(m.responses, k)
+			}
+		}
+
+		m.lastPrune = now
+	}
+
+	m.responses[key] = memoryEntry{expires: expires, resp: resp}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipfilter
+
+import (
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/visiosto/bifrost/internal/config"
+)
+
+const pruneInterval = time.Minute
+
+// Ban is a temporary ban of an IP address.
+type Ban struct {
+	Until  time.Time  `json:"until"`
+	Reason string     `json:"reason"`
+	IP     netip.Addr `json:"ip"`
+}
+
+// Bans keeps track of the strikes and the bans of the IP addresses. An IP
+// address is banned when it gets enough strikes within the window.
+type Bans struct {
+	strikes   map[netip.Addr]*strikes
+	bans      map[netip.Addr]Ban
+	lastPrune time.Time
+	threshold int
+	window    time.Duration
+	duration  time.Duration
+	mu        sync.Mutex
+}
+
+type strikes struct {
+	resetAt time.Time
+	count   int
+}
+
+// NewBans returns Bans for the config. The IP addresses are banned
+// automatically only if the threshold is set.
+func NewBans(cfg *config.Bans) *Bans {
+	return &Bans{
+		strikes:   map[netip.Addr]*strikes{},
+		bans:      map[netip.Addr]Ban{},
+		lastPrune: time.Time{},
+		threshold: cfg.Threshold,
+		window:    time.Duration(cfg.WindowSeconds) * time.Second,
+		duration:  time.Duration(cfg.DurationSeconds) * time.Second,
+		mu:        sync.Mutex{},
+	}
+}
+
+// Strike records a strike for the IP address. It returns true if
+// the address was banned because of the strike.
+func (b *Bans) Strike(addr netip.Addr, reason string) bool {
+	if b.threshold == 0 {
+		return false
+	}
+
+	addr = addr.Unmap()
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.prune(now)
+
+	if ban, ok := b.bans[addr]; ok && now.Before(ban.Until) {
+		return false
+	}
+
+	s, ok := b.strikes[addr]
+	if !ok || now.After(s.resetAt) {
+		s = &strikes{resetAt: now.Add(b.window), count: 0}
+		b.strikes[addr] = s
+	}
+
+	s.count++
+
+	if s.count < b.threshold {
+		return false
+	}
+
+	delete(b.strikes, addr)
+
+	b.bans[addr] = Ban{Until: now.Add(b.duration), Reason: reason, IP: addr}
+
+	return true
+}
+
+// Ban bans the IP address for the duration.
+func (b *Bans) Ban(addr netip.Addr, d time.Duration, reason string) Ban {
+	addr = addr.Unmap()
+	ban := Ban{Until: time.Now().Add(d), Reason: reason, IP: addr}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.bans[addr] = ban
+
+	return ban
+}
+
+// Unban lifts the ban of the IP address. It returns false if the address
+// was not banned.
+func (b *Bans) Unban(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ban, ok := b.bans[addr]
+	delete(b.bans, addr)
+	delete(b.strikes, addr)
+
+	return ok && time.Now().Before(ban.Until)
+}
+
+// Banned reports whether the IP address is currently banned.
+func (b *Bans) Banned(addr netip.Addr) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ban, ok := b.bans[addr.Unmap()]
+
+	return ok && time.Now().Before(ban.Until)
+}
+
+// List returns the current bans ordered by the IP address.
+func (b *Bans) List() []Ban {
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.prune(now)
+
+	result := make([]Ban, 0, len(b.bans))
+
+	for _, ban := range b.bans {
+		if now.Before(ban.Until) {
+			result = append(result, ban)
+		}
+	}
+
+	slices.SortFunc(result, func(a, b Ban) int { return a.IP.Compare(b.IP) })
+
+	return result
+}
+
+// prune removes the expired bans and strikes. It must be called with
+// the lock held.
+func (b *Bans) prune(now time.Time) {
+	if now.Sub(b.lastPrune) < pruneInterval {
+		return
+	}
+
+	for addr, ban := range b.bans {
+		if now.After(ban.Until) {
+			delete(b.bans, addr)
+		}
+	}
+
+	for addr, s := range b.strikes {
+		if now.After(s.resetAt) {
+			delete(b.strikes, addr)
+		}
+	}
+
+	b.lastPrune = now
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ipfilter implements the IP address block and allow lists and
+// the temporary bans.
+package ipfilter
+
+import (
+	"net/netip"
+	"slices"
+
+	"github.com/visiosto/bifrost/internal/config"
+)
+
+// Decisions of the filter.
+const (
+	Allow Decision = iota
+	Blocked
+	Banned
+)
+
+// Decision is the result of checking an IP address against the filter.
+type Decision int
+
+// Filter checks the IP addresses against the global and per-site block and
+// allow lists and the bans. The allowed networks are exempt from both
+// the blocked networks and the bans.
+type Filter struct {
+	bans   *Bans
+	global lists
+	sites  map[string]lists
+}
+
+type lists struct {
+	blocked []netip.Prefix
+	allowed []netip.Prefix
+}
+
+// New returns a Filter for the lists in the config that also checks the bans.
+func New(cfg *config.Config, bans *Bans) (*Filter, error) {
+	global, err := parseLists(cfg.BlockedCIDRs, cfg.AllowedCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Filter{bans: bans, global: global, sites: map[string]lists{}}
+
+	for _, site := range cfg.Sites {
+		l, err := parseLists(site.BlockedCIDRs, site.AllowedCIDRs)
+		if err != nil {
+			return nil, err
+		}
+
+		f.sites[site.ID] = l
+	}
+
+	return f, nil
+}
+
+// Check returns the decision for the IP address on the site.
+func (f *Filter) Check(site string, addr netip.Addr) Decision {
+	addr = addr.Unmap()
+	l := f.sites[site]
+
+	if contains(f.global.allowed, addr) || contains(l.allowed, addr) {
+		return Allow
+	}
+
+	if contains(f.global.blocked, addr) || contains(l.blocked, addr) {
+		return Blocked
+	}
+
+	if f.bans.Banned(addr) {
+		return Banned
+	}
+
+	return Allow
+}
+
+func (d Decision) String() string {
+	switch d {
+	case Allow:
+		return "allow"
+	case Blocked:
+		return "blocked"
+	case Banned:
+		return "banned"
+	default:
+		return "invalid-decision"
+	}
+}
+
+func parseLists(blocked, allowed []string) (lists, error) {
+	b, err := config.ParseCIDRs(blocked)
+	if err != nil {
+		return lists{}, err //nolint:exhaustruct // zero value on error
+	}
+
+	a, err := config.ParseCIDRs(allowed)
+	if err != nil {
+		return lists{}, err //nolint:exhaustruct // zero value on error
+	}
+
+	return lists{blocked: b, allowed: a}, nil
+}
+
+func contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	return slices.ContainsFunc(prefixes, func(p netip.Prefix) bool { return p.Contains(addr) })
+}
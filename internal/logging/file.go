@@ -0,0 +1,98 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// File is a log file that can be reopened, for example after logrotate has
+// moved it. It is safe for concurrent use.
+type File struct {
+	f    *os.File
+	path string
+	mu   sync.Mutex
+}
+
+// OpenFile opens the log file at the given path for appending, creating it if
+// needed.
+func OpenFile(path string) (*File, error) {
+	f, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &File{f: f, path: path, mu: sync.Mutex{}}, nil
+}
+
+// Write implements [io.Writer].
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n, err := f.f.Write(p)
+	if err != nil {
+		return n, fmt.Errorf("failed to write to log file: %w", err)
+	}
+
+	return n, nil
+}
+
+// Reopen closes the current file and opens the file at the original path
+// again. If opening the new file fails, the old file is kept open.
+func (f *File) Reopen() error {
+	newFile, err := openLogFile(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	old := f.f
+	f.f = newFile
+
+	err = old.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close the old log file: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the file.
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	err := f.f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+
+	return nil
+}
+
+func openLogFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(filepath.Clean(path), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o640) //nolint:mnd // file mode
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file at %q: %w", path, err)
+	}
+
+	return f, nil
+}
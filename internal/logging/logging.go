@@ -0,0 +1,155 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logging sets up the structured logging of Bifröst.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+
+	"github.com/visiosto/bifrost/internal/config"
+)
+
+const ctxKeyRequestID ctxKey = iota
+
+var errLogConfig = errors.New("invalid log config")
+
+type ctxKey int
+
+// contextHandler is a [slog.Handler] that adds the request-scoped values from
+// the context to each record.
+type contextHandler struct {
+	slog.Handler
+
+	// root is the handler before the first group. The request-scoped values
+	// are added to it so that they stay at the top level of the records.
+	root slog.Handler
+
+	// groups apply the groups and the attributes that were added after
+	// the first group to the root handler.
+	groups []func(slog.Handler) slog.Handler
+}
+
+// New creates the logger according to the config. If the logs are written to
+// a file, the file is reopened when the program receives SIGUSR1 until ctx is
+// canceled. The returned function closes the log output.
+func New(ctx context.Context, cfg *config.Config) (*slog.Logger, func() error, error) {
+	var (
+		out      io.Writer
+		closeOut = func() error { return nil }
+	)
+
+	switch cfg.LogOutput {
+	case "", config.LogOutputStdout:
+		out = os.Stdout
+	case config.LogOutputStderr:
+		out = os.Stderr
+	default:
+		f, err := OpenFile(cfg.LogOutput)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		reopenOnSignal(ctx, f)
+
+		out = f
+		closeOut = f.Close
+	}
+
+	opts := &slog.HandlerOptions{ //nolint:exhaustruct // no need for value
+		AddSource: false,
+		Level:     cfg.LogLevel,
+	}
+
+	var h slog.Handler
+
+	switch cfg.LogFormat {
+	case config.LogFormatText:
+		h = slog.NewTextHandler(out, opts)
+	case config.LogFormatJSON:
+		h = slog.NewJSONHandler(out, opts)
+	default:
+		return nil, nil, fmt.Errorf("%w: %s", errLogConfig, cfg.LogFormat)
+	}
+
+	return slog.New(&contextHandler{Handler: h, root: h, groups: nil}), closeOut, nil
+}
+
+// WithRequestID returns a copy of ctx that carries the given request ID. It is
+// added to all of the log records that are logged with the returned context.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKeyRequestID, id)
+}
+
+// RequestID returns the request ID in ctx.
+func RequestID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(ctxKeyRequestID).(string)
+
+	return id, ok
+}
+
+// Handle implements [slog.Handler].
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	id, ok := RequestID(ctx)
+	if !ok {
+		return h.Handler.Handle(ctx, r) //nolint:wrapcheck // pass through the handler error
+	}
+
+	attr := slog.String("request_id", id)
+
+	if len(h.groups) == 0 {
+		r.AddAttrs(attr)
+
+		return h.Handler.Handle(ctx, r) //nolint:wrapcheck // pass through the handler error
+	}
+
+	// The record attributes would be added to the open group, so the request
+	// ID is added to the root handler before the groups are applied again.
+	handler := h.root.WithAttrs([]slog.Attr{attr})
+	for _, apply := range h.groups {
+		handler = apply(handler)
+	}
+
+	return handler.Handle(ctx, r) //nolint:wrapcheck // pass through the handler error
+}
+
+// WithAttrs implements [slog.Handler].
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := h.Handler.WithAttrs(attrs)
+
+	if len(h.groups) == 0 {
+		return &contextHandler{Handler: next, root: next, groups: nil}
+	}
+
+	return &contextHandler{
+		Handler: next,
+		root:    h.root,
+		groups:  append(slices.Clip(h.groups), func(h slog.Handler) slog.Handler { return h.WithAttrs(attrs) }),
+	}
+}
+
+// WithGroup implements [slog.Handler].
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{
+		Handler: h.Handler.WithGroup(name),
+		root:    h.root,
+		groups:  append(slices.Clip(h.groups), func(h slog.Handler) slog.Handler { return h.WithGroup(name) }),
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// reopenOnSignal reopens f each time the program receives SIGUSR1 until ctx
+// is canceled.
+func reopenOnSignal(ctx context.Context, f *File) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGUSR1)
+
+	go func() {
+		defer signal.Stop(sigCh)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sigCh:
+				err := f.Reopen()
+				if err != nil {
+					slog.ErrorContext(ctx, "failed to reopen log file", "path", f.path, "err", err)
+
+					continue
+				}
+
+				slog.InfoContext(ctx, "reopened log file", "path", f.path)
+			}
+		}
+	}()
+}
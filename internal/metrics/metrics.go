@@ -0,0 +1,221 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics defines the Prometheus metrics that Bifröst collects.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "bifrost"
+
+// Metrics contains the Prometheus collectors of the program. The collectors are
+// always updated, but they are only exposed if the metrics endpoint is enabled.
+type Metrics struct {
+	registry            *prometheus.Registry
+	requests            *prometheus.CounterVec
+	requestDuration     *prometheus.HistogramVec
+	validationFailures  *prometheus.CounterVec
+	honeypotHits        *prometheus.CounterVec
+	rateLimitRejections *prometheus.CounterVec
+	spamSubmissions     *prometheus.CounterVec
+	duplicates          *prometheus.CounterVec
+	blockedRequests     *prometheus.CounterVec
+	bans                *prometheus.CounterVec
+	notifierSends       *prometheus.CounterVec
+	notifierFailures    *prometheus.CounterVec
+	notifierDuration    *prometheus.HistogramVec
+}
+
+// New allocates and returns a new Metrics with all of the collectors
+// registered to a new registry.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{ //nolint:exhaustruct // use defaults
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Total number of HTTP requests by site, form, and status code.",
+		}, []string{"site", "form", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{ //nolint:exhaustruct // use defaults
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of the HTTP requests by site, form, and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"site", "form", "status"}),
+		validationFailures: prometheus.NewCounterVec(prometheus.CounterOpts{ //nolint:exhaustruct // use defaults
+			Namespace: namespace,
+			Subsystem: "forms",
+			Name:      "validation_failures_total",
+			Help:      "Total number of rejected form payloads by site, form, and reason.",
+		}, []string{"site", "form", "reason"}),
+		honeypotHits: prometheus.NewCounterVec(prometheus.CounterOpts{ //nolint:exhaustruct // use defaults
+			Namespace: namespace,
+			Subsystem: "forms",
+			Name:      "honeypot_hits_total",
+			Help:      "Total number of form submissions that had the honeypot field set.",
+		}, []string{"site", "form"}),
+		rateLimitRejections: prometheus.NewCounterVec(prometheus.CounterOpts{ //nolint:exhaustruct // use defaults
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "rate_limit_rejections_total",
+			Help:      "Total number of requests rejected by the rate limiter by site.",
+		}, []string{"site"}),
+		spamSubmissions: prometheus.NewCounterVec(prometheus.CounterOpts{ //nolint:exhaustruct // use defaults
+			Namespace: namespace,
+			Subsystem: "forms",
+			Name:      "spam_submissions_total",
+			Help:      "Total number of form submissions scored as spam by site, form, and action.",
+		}, []string{"site", "form", "action"}),
+		duplicates: prometheus.NewCounterVec(prometheus.CounterOpts{ //nolint:exhaustruct // use defaults
+			Namespace: namespace,
+			Subsystem: "forms",
+			Name:      "duplicate_submissions_total",
+			Help:      "Total number of repeated form submissions that got the original response by site and form.",
+		}, []string{"site", "form"}),
+		blockedRequests: prometheus.NewCounterVec(prometheus.CounterOpts{ //nolint:exhaustruct // use defaults
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "blocked_requests_total",
+			Help:      "Total number of requests rejected by the IP filter by site and reason.",
+		}, []string{"site", "reason"}),
+		bans: prometheus.NewCounterVec(prometheus.CounterOpts{ //nolint:exhaustruct // use defaults
+			Namespace: namespace,
+			Subsystem: "ipfilter",
+			Name:      "bans_total",
+			Help:      "Total number of automatic IP address bans by reason.",
+		}, []string{"reason"}),
+		notifierSends: prometheus.NewCounterVec(prometheus.CounterOpts{ //nolint:exhaustruct // use defaults
+			Namespace: namespace,
+			Subsystem: "notifier",
+			Name:      "send_attempts_total",
+			Help:      "Total number of notification send attempts by backend.",
+		}, []string{"backend"}),
+		notifierFailures: prometheus.NewCounterVec(prometheus.CounterOpts{ //nolint:exhaustruct // use defaults
+			Namespace: namespace,
+			Subsystem: "notifier",
+			Name:      "send_failures_total",
+			Help:      "Total number of failed notification sends by backend.",
+		}, []string{"backend"}),
+		notifierDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{ //nolint:exhaustruct // use defaults
+			Namespace: namespace,
+			Subsystem: "notifier",
+			Name:      "send_duration_seconds",
+			Help:      "Latency of the notification sends by backend.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"backend"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}), //nolint:exhaustruct // use defaults
+		m.requests,
+		m.requestDuration,
+		m.validationFailures,
+		m.honeypotHits,
+		m.rateLimitRejections,
+		m.spamSubmissions,
+		m.duplicates,
+		m.blockedRequests,
+		m.bans,
+		m.notifierSends,
+		m.notifierFailures,
+		m.notifierDuration,
+	)
+
+	return m
+}
+
+// Handler returns the [http.Handler] that serves the collected metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}) //nolint:exhaustruct // use defaults
+}
+
+// RegisterLimiterBuckets registers a gauge that reports the number of buckets
+// in the rate limiter by calling f on each scrape.
+func (m *Metrics) RegisterLimiterBuckets(f func() float64) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{ //nolint:exhaustruct // use defaults
+		Namespace: namespace,
+		Subsystem: "limiter",
+		Name:      "buckets",
+		Help:      "Current number of buckets in the rate limiter.",
+	}, f))
+}
+
+// ObserveRequest records a handled HTTP request.
+func (m *Metrics) ObserveRequest(site, form string, status int, d time.Duration) {
+	code := strconv.Itoa(status)
+
+	m.requests.WithLabelValues(site, form, code).Inc()
+	m.requestDuration.WithLabelValues(site, form, code).Observe(d.Seconds())
+}
+
+// ValidationFailure records a form payload that was rejected for the given
+// reason.
+func (m *Metrics) ValidationFailure(site, form, reason string) {
+	m.validationFailures.WithLabelValues(site, form, reason).Inc()
+}
+
+// HoneypotHit records a form submission that had the honeypot field set.
+func (m *Metrics) HoneypotHit(site, form string) {
+	m.honeypotHits.WithLabelValues(site, form).Inc()
+}
+
+// RateLimitRejection records a request that was rejected by the rate limiter.
+func (m *Metrics) RateLimitRejection(site string) {
+	m.rateLimitRejections.WithLabelValues(site).Inc()
+}
+
+// SpamSubmission records a form submission that was scored as spam and
+// handled using the given action.
+func (m *Metrics) SpamSubmission(site, form, action string) {
+	m.spamSubmissions.WithLabelValues(site, form, action).Inc()
+}
+
+// DuplicateSubmission records a repeated form submission that got
+// the original response.
+func (m *Metrics) DuplicateSubmission(site, form string) {
+	m.duplicates.WithLabelValues(site, form).Inc()
+}
+
+// BlockedRequest records a request that was rejected by the IP filter for
+// the given reason.
+func (m *Metrics) BlockedRequest(site, reason string) {
+	m.blockedRequests.WithLabelValues(site, reason).Inc()
+}
+
+// Ban records an automatic ban of an IP address.
+func (m *Metrics) Ban(reason string) {
+	m.bans.WithLabelValues(reason).Inc()
+}
+
+// NotifierSend records a notification send attempt using the given backend.
+// The attempt is counted as failed if err is not nil.
+func (m *Metrics) NotifierSend(backend string, d time.Duration, err error) {
+	m.notifierSends.WithLabelValues(backend).Inc()
+	m.notifierDuration.WithLabelValues(backend).Observe(d.Seconds())
+
+	if err != nil {
+		m.notifierFailures.WithLabelValues(backend).Inc()
+	}
+}
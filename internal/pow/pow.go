@@ -0,0 +1,242 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pow issues and verifies the stateless proof-of-work challenges
+// for the form submissions. A challenge is solved by finding a nonce so that
+// the SHA-256 hash of "<challenge>:<nonce>" has at least the number of
+// leading zero bits that is embedded in the challenge.
+package pow
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Algorithm is the name of the hash algorithm of the challenges.
+const Algorithm = "SHA-256"
+
+const (
+	nonceBytes    = 12
+	pruneInterval = time.Minute
+)
+
+var (
+	// ErrMalformed is returned when the solution or the challenge cannot be
+	// parsed.
+	ErrMalformed = errors.New("malformed proof-of-work solution")
+
+	// ErrSignature is returned when the signature of the challenge is invalid
+	// or the challenge was issued for another form.
+	ErrSignature = errors.New("invalid proof-of-work challenge signature")
+
+	// ErrExpired is returned when the challenge is too old.
+	ErrExpired = errors.New("proof-of-work challenge expired")
+
+	// ErrSpent is returned when the challenge has already been used.
+	ErrSpent = errors.New("proof-of-work challenge already used")
+
+	// ErrInsufficient is returned when the hash of the solution does not have
+	// enough leading zero bits.
+	ErrInsufficient = errors.New("insufficient proof of work")
+)
+
+// Challenger issues and verifies the proof-of-work challenges of a form.
+// The challenges are not stored, but the used ones are remembered until
+// they expire so that a solution cannot be reused.
+type Challenger struct {
+	spent     map[string]time.Time
+	lastPrune time.Time
+	key       []byte
+	site      string
+	form      string
+	maxAge    time.Duration
+	mu        sync.Mutex
+}
+
+// NewChallenger returns a Challenger for the form that signs the challenges
+// with the given secret. The challenges expire after maxAge.
+func NewChallenger(secret, site, form string, maxAge time.Duration) *Challenger {
+	return &Challenger{
+		spent:     map[string]time.Time{},
+		lastPrune: time.Time{},
+		key:       []byte(secret),
+		site:      site,
+		form:      form,
+		maxAge:    maxAge,
+		mu:        sync.Mutex{},
+	}
+}
+
+// Issue returns a new challenge with the given difficulty in bits.
+func (c *Challenger) Issue(difficulty int, now time.Time) (string, error) {
+	var nonce [nonceBytes]byte
+
+	_, err := rand.Read(nonce[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to generate challenge nonce: %w", err)
+	}
+
+	payload := strings.Join([]string{
+		c.site,
+		c.form,
+		strconv.FormatInt(now.Unix(), 10),
+		strconv.Itoa(difficulty),
+		base64.RawURLEncoding.EncodeToString(nonce[:]),
+	}, "/")
+
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(c.sign(payload)), nil
+}
+
+// Verify checks the solution "<challenge>:<nonce>". The challenge must have
+// been issued by c, it must not be expired or used before, and its
+// difficulty must be at least minDifficulty. The challenge is marked as used
+// if the solution is valid, and it can be released with [Challenger.Release]
+// if the submission fails because of the server.
+func (c *Challenger) Verify(solution string, minDifficulty int, now time.Time) error {
+	i := strings.LastIndexByte(solution, ':')
+	if i < 0 {
+		return ErrMalformed
+	}
+
+	challenge := solution[:i]
+
+	issued, difficulty, err := c.parse(challenge)
+	if err != nil {
+		return err
+	}
+
+	if now.Sub(issued) > c.maxAge {
+		return ErrExpired
+	}
+
+	if difficulty < minDifficulty {
+		return fmt.Errorf("%w: difficulty %d is less than %d", ErrInsufficient, difficulty, minDifficulty)
+	}
+
+	sum := sha256.Sum256([]byte(solution))
+	if leadingZeros(sum[:]) < difficulty {
+		return ErrInsufficient
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.spent[challenge]; ok {
+		return ErrSpent
+	}
+
+	if now.Sub(c.lastPrune) > pruneInterval {
+		for k, expires := range c.spent {
+			if now.After(expires) {
+				delete(c.spent, k)
+			}
+		}
+
+		c.lastPrune = now
+	}
+
+	c.spent[challenge] = issued.Add(c.maxAge)
+
+	return nil
+}
+
+// Release marks the challenge of the solution as unused so that the same
+// solution can be verified again.
+func (c *Challenger) Release(solution string) {
+	i := strings.LastIndexByte(solution, ':')
+	if i < 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.spent, solution[:i])
+}
+
+func (c *Challenger) parse(challenge string) (time.Time, int, error) {
+	encPayload, encSig, ok := strings.Cut(challenge, ".")
+	if !ok {
+		return time.Time{}, 0, ErrMalformed
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return time.Time{}, 0, ErrMalformed
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(encSig)
+	if err != nil {
+		return time.Time{}, 0, ErrMalformed
+	}
+
+	if !hmac.Equal(sig, c.sign(string(payload))) {
+		return time.Time{}, 0, ErrSignature
+	}
+
+	// The site and form IDs may contain slashes so the payload is parsed
+	// from the end.
+	parts := strings.Split(string(payload), "/")
+	if len(parts) < 5 { //nolint:mnd // site, form, time, difficulty, and nonce
+		return time.Time{}, 0, ErrMalformed
+	}
+
+	n := len(parts)
+	if strings.Join(parts[:n-3], "/") != c.site+"/"+c.form {
+		return time.Time{}, 0, ErrSignature
+	}
+
+	issued, err := strconv.ParseInt(parts[n-3], 10, 64)
+	if err != nil {
+		return time.Time{}, 0, ErrMalformed
+	}
+
+	difficulty, err := strconv.Atoi(parts[n-2])
+	if err != nil {
+		return time.Time{}, 0, ErrMalformed
+	}
+
+	return time.Unix(issued, 0), difficulty, nil
+}
+
+func (c *Challenger) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(payload))
+
+	return mac.Sum(nil)
+}
+
+func leadingZeros(b []byte) int {
+	n := 0
+
+	for _, x := range b {
+		if x != 0 {
+			return n + bits.LeadingZeros8(x)
+		}
+
+		n += 8
+	}
+
+	return n
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package readiness implements the dependency checks that decide whether
+// the server is ready to receive traffic.
+package readiness
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Check statuses.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check is a single readiness check.
+type Check struct {
+	// Run runs the check. It returns an error if the dependency is not usable.
+	Run func(ctx context.Context) error
+
+	// Name is the unique name of the check that is shown in the report.
+	Name string
+
+	// Critical controls whether a failure of the check makes the whole server
+	// not ready. Non-critical failures are only reported.
+	Critical bool
+}
+
+// Result is the result of a single check.
+type Result struct {
+	CheckedAt time.Time `json:"checkedAt"`
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+
+	// Error is the error of a failed check. It is not included in
+	// the report as it may reveal details of the dependencies.
+	Error      string `json:"-"`
+	DurationMS int64  `json:"durationMs"`
+	Critical   bool   `json:"critical"`
+}
+
+// Report is the combined result of all of the checks.
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Checker runs the registered checks and caches their results so that
+// frequent probes from load balancers do not hammer the dependencies.
+type Checker struct {
+	results map[string]Result
+	checks  []Check
+	ttl     time.Duration
+	timeout time.Duration
+	mu      sync.Mutex
+}
+
+// NewChecker returns a new Checker that caches the check results for ttl and
+// cancels each check after timeout.
+func NewChecker(ttl, timeout time.Duration) *Checker {
+	return &Checker{
+		results: map[string]Result{},
+		checks:  nil,
+		ttl:     ttl,
+		timeout: timeout,
+		mu:      sync.Mutex{},
+	}
+}
+
+// Register adds a check to the checker. It must not be called after the first
+// call to [Checker.Run].
+func (c *Checker) Register(check Check) {
+	c.checks = append(c.checks, check)
+}
+
+// Run runs the checks whose cached results have expired and returns
+// the report. The report status is [StatusFail] if any of the critical checks
+// fails. The checks are not canceled with ctx so that a canceled request does
+// not leave a failed result in the cache, and they only stop at the timeout.
+func (c *Checker) Run(ctx context.Context) Report {
+	ctx = context.WithoutCancel(ctx)
+
+	now := time.Now()
+	results := make([]Result, len(c.checks))
+
+	var wg sync.WaitGroup
+
+	for i, check := range c.checks {
+		c.mu.Lock()
+		cached, ok := c.results[check.Name]
+		c.mu.Unlock()
+
+		if ok && now.Sub(cached.CheckedAt) < c.ttl {
+			results[i] = cached
+
+			continue
+		}
+
+		wg.Go(func() {
+			result := c.run(ctx, check)
+
+			c.mu.Lock()
+			c.results[check.Name] = result
+			c.mu.Unlock()
+
+			results[i] = result
+		})
+	}
+
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: results}
+
+	for _, result := range results {
+		if result.Critical && result.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	result := Result{
+		CheckedAt:  start,
+		Name:       check.Name,
+		Status:     StatusOK,
+		Error:      "",
+		DurationMS: time.Since(start).Milliseconds(),
+		Critical:   check.Critical,
+	}
+
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	return result
+}
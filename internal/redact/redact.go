@@ -0,0 +1,120 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package redact removes secrets and personal data from the values that are
+// logged.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	"github.com/visiosto/bifrost/internal/config"
+)
+
+// Redacted is the value that replaces the redacted values.
+const Redacted = "[REDACTED]"
+
+// hashPrefixLen is the number of hex characters of the hash that are kept.
+const hashPrefixLen = 12
+
+// defaultHeaders are the headers that are always redacted.
+//
+//nolint:gochecknoglobals // constant list of headers
+var defaultHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Proxy-Authorization",
+	"Set-Cookie",
+	config.SiteTokenHeader,
+	config.FormTokenHeader,
+}
+
+// Redactor redacts the values according to the config.
+type Redactor struct {
+	headers map[string]struct{}
+	ipKey   []byte
+	hashIPs bool
+}
+
+// New returns a new Redactor for the given config. If the client IPs are
+// hashed and no key is configured, a random key is generated so that
+// the hashes can be correlated only within the lifetime of the process.
+func New(cfg *config.Redaction) (*Redactor, error) {
+	headers := make(map[string]struct{}, len(defaultHeaders)+len(cfg.Headers))
+
+	for _, name := range defaultHeaders {
+		headers[http.CanonicalHeaderKey(name)] = struct{}{}
+	}
+
+	for _, name := range cfg.Headers {
+		headers[http.CanonicalHeaderKey(name)] = struct{}{}
+	}
+
+	key := []byte(cfg.IPHashKey)
+
+	if cfg.HashClientIPs && len(key) == 0 {
+		key = make([]byte, sha256.Size)
+
+		_, err := rand.Read(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate IP hash key: %w", err)
+		}
+	}
+
+	return &Redactor{headers: headers, ipKey: key, hashIPs: cfg.HashClientIPs}, nil
+}
+
+// Token returns a short prefix of the SHA-256 hash of the token so that
+// the logged value can be compared to the known tokens without revealing it.
+func Token(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return "sha256:" + hex.EncodeToString(sum[:])[:hashPrefixLen]
+}
+
+// Headers returns a copy of h where the values of the denied headers are
+// replaced with [Redacted].
+func (r *Redactor) Headers(h http.Header) http.Header {
+	result := h.Clone()
+
+	for name, values := range result {
+		if _, ok := r.headers[name]; !ok {
+			continue
+		}
+
+		for i := range values {
+			values[i] = Redacted
+		}
+	}
+
+	return result
+}
+
+// IP returns the client IP address as is or as a keyed hash if the client IPs
+// should be hashed.
+func (r *Redactor) IP(ip string) string {
+	if !r.hashIPs {
+		return ip
+	}
+
+	mac := hmac.New(sha256.New, r.ipKey)
+	mac.Write([]byte(ip))
+
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil))[:hashPrefixLen]
+}
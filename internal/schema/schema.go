@@ -0,0 +1,242 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package schema derives the JSON Schema and the TypeScript declarations of
+// the payloads of the forms from the config so that the sites do not have to
+// copy the field definitions by hand.
+package schema
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/visiosto/bifrost/internal/config"
+)
+
+// Draft is the JSON Schema dialect of the generated schemas.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// decimalPattern is the pattern of the decimal numbers given as strings.
+const decimalPattern = `^[+-]?\d+(\.\d+)?$`
+
+// JSONSchema returns the JSON Schema of the payload of the form. The schema
+// describes the types, the required fields, the bounds, the shapes of
+// the objects, the honeypot field, and the payload fields of the CAPTCHA,
+// the time trap, and the proof of work. The lengths are only described for
+// the fields that count the length in characters as that is what JSON Schema
+// counts. The precision is only described in the pattern of the decimal
+// strings, as "multipleOf" with a fractional divisor is unreliable with
+// the floating-point numbers.
+func JSONSchema(form *config.Form) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+
+	for name, field := range form.Fields {
+		if name == form.HoneypotField {
+			properties[name] = map[string]any{
+				"type":        "string",
+				"maxLength":   0,
+				"description": "Honeypot field that must be left empty.",
+			}
+
+			continue
+		}
+
+		properties[name] = fieldSchema(&field)
+
+		if field.Required {
+			required = append(required, name)
+		}
+	}
+
+	if form.Captcha != nil {
+		properties[form.Captcha.TokenField] = map[string]any{
+			"type":        "string",
+			"description": "Response token of the CAPTCHA widget.",
+		}
+		required = append(required, form.Captcha.TokenField)
+	}
+
+	if form.TimeTrap != nil {
+		properties[form.TimeTrap.Field] = map[string]any{
+			"type":        "string",
+			"description": "Signed timestamp issued by the timestamp endpoint of the form.",
+		}
+		required = append(required, form.TimeTrap.Field)
+	}
+
+	if form.ProofOfWork != nil {
+		properties[form.ProofOfWork.Field] = map[string]any{
+			"type":        "string",
+			"description": "Solution to the proof-of-work challenge unless it is sent in the " + config.PowHeader + " header.",
+		}
+	}
+
+	slices.Sort(required)
+
+	schema := map[string]any{
+		"$schema":              Draft,
+		"title":                form.ID,
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+//nolint:cyclop // one case per field type
+func fieldSchema(field *config.FormField) map[string]any {
+	schema := map[string]any{}
+
+	if field.DisplayName != "" {
+		schema["title"] = field.DisplayName
+	}
+
+	switch field.Type {
+	case config.FormFieldBool:
+		schema["type"] = "boolean"
+
+		if field.Required {
+			schema["const"] = true
+		}
+	case config.FormFieldInt:
+		schema["type"] = "integer"
+		setBounds(schema, field, "minimum", "maximum")
+	case config.FormFieldNumber:
+		schema["type"] = "number"
+		setBounds(schema, field, "minimum", "maximum")
+	case config.FormFieldDecimal:
+		// The bounds only apply to the numbers and the pattern only to
+		// the strings.
+		schema["type"] = []string{"string", "number"}
+		schema["pattern"] = decimalPattern
+
+		if field.Precision != nil {
+			schema["pattern"] = precisionPattern(*field.Precision)
+		}
+		setBounds(schema, field, "minimum", "maximum")
+	case config.FormFieldString, config.FormFieldURL, config.FormFieldPhone:
+		schema["type"] = "string"
+		setText(schema, field)
+
+		if field.Type == config.FormFieldURL {
+			schema["format"] = "uri"
+		}
+	case config.FormFieldDate:
+		schema["type"] = "string"
+		schema["format"] = "date"
+	case config.FormFieldDateTime:
+		schema["type"] = "string"
+		schema["format"] = "date-time"
+	case config.FormFieldEnum:
+		values := optionValues(field)
+		if !field.Required {
+			// An empty string is no selection.
+			values = append(values, "")
+		}
+
+		schema["type"] = "string"
+		schema["enum"] = values
+	case config.FormFieldEnumList:
+		schema["type"] = "array"
+		schema["items"] = map[string]any{"type": "string", "enum": optionValues(field)}
+		setBounds(schema, field, "minItems", "maxItems")
+	case config.FormFieldObjects:
+		schema["type"] = "array"
+		schema["items"] = shapeSchema(field.Shape)
+		setBounds(schema, field, "minItems", "maxItems")
+
+		if field.Required && field.Min == nil {
+			schema["minItems"] = 1
+		}
+	default:
+	}
+
+	return schema
+}
+
+func shapeSchema(shape config.FormFieldShape) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+
+	for key, field := range shape {
+		properties[key] = fieldSchema(&field)
+
+		if field.Required {
+			required = append(required, key)
+		}
+	}
+
+	slices.Sort(required)
+
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+func setBounds(schema map[string]any, field *config.FormField, minKey, maxKey string) {
+	if field.Min != nil {
+		schema[minKey] = *field.Min
+	}
+
+	if field.Max != nil {
+		schema[maxKey] = *field.Max
+	}
+}
+
+func setText(schema map[string]any, field *config.FormField) {
+	if field.LengthUnit == config.LengthCharacters {
+		setBounds(schema, field, "minLength", "maxLength")
+	}
+
+	if field.Required && field.Min == nil {
+		schema["minLength"] = 1
+	}
+
+	if field.Pattern != "" {
+		schema["pattern"] = field.Pattern
+	}
+}
+
+// precisionPattern returns the pattern of the decimal numbers given as strings
+// that have at most the given number of decimal places.
+func precisionPattern(precision int) string {
+	if precision == 0 {
+		return `^[+-]?\d+$`
+	}
+
+	return `^[+-]?\d+(\.\d{1,` + strconv.Itoa(precision) + `})?$`
+}
+
+func optionValues(field *config.FormField) []string {
+	values := make([]string, 0, len(field.Options))
+	for _, option := range field.Options {
+		values = append(values, option.Value)
+	}
+
+	return values
+}
@@ -0,0 +1,181 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/visiosto/bifrost/internal/config"
+)
+
+const indent = "  "
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// TypeName returns the default name of the TypeScript interface of the form,
+// for example "ContactForm" for the form "contact".
+func TypeName(form *config.Form) string {
+	var b strings.Builder
+
+	for part := range strings.FieldsFuncSeq(form.ID, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+
+	name := b.String() + "Form"
+	if !identifierPattern.MatchString(name) {
+		name = "_" + name
+	}
+
+	return name
+}
+
+// TypeScript writes the TypeScript declaration of the payload of the form as
+// an exported interface with the given name. The honeypot field is left out
+// as the sites must not set it. The fields of the CAPTCHA and the time trap
+// are required like in [JSONSchema].
+func TypeScript(w io.Writer, name string, form *config.Form) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "export interface %s {\n", name)
+
+	names := make([]string, 0, len(form.Fields))
+
+	for fieldName := range form.Fields {
+		if fieldName != form.HoneypotField {
+			names = append(names, fieldName)
+		}
+	}
+
+	slices.Sort(names)
+
+	for _, fieldName := range names {
+		field := form.Fields[fieldName]
+		writeProperty(&b, indent, fieldName, &field)
+	}
+
+	if form.Captcha != nil {
+		fmt.Fprintf(&b, "%s%s: string;\n", indent, propertyName(form.Captcha.TokenField))
+	}
+
+	if form.TimeTrap != nil {
+		fmt.Fprintf(&b, "%s%s: string;\n", indent, propertyName(form.TimeTrap.Field))
+	}
+
+	// The proof-of-work solution is optional as it can be sent in a header.
+	if form.ProofOfWork != nil {
+		fmt.Fprintf(&b, "%s%s?: string;\n", indent, propertyName(form.ProofOfWork.Field))
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	if err != nil {
+		return fmt.Errorf("failed to write TypeScript declaration: %w", err)
+	}
+
+	return nil
+}
+
+func writeProperty(b *strings.Builder, prefix, name string, field *config.FormField) {
+	if field.DisplayName != "" {
+		fmt.Fprintf(b, "%s/** %s */\n", prefix, strings.ReplaceAll(field.DisplayName, "*/", "*\\/"))
+	}
+
+	optional := "?"
+	if field.Required {
+		optional = ""
+	}
+
+	fmt.Fprintf(b, "%s%s%s: %s;\n", prefix, propertyName(name), optional, tsType(prefix, field))
+}
+
+func tsType(prefix string, field *config.FormField) string {
+	switch field.Type {
+	case config.FormFieldBool:
+		if field.Required {
+			return "true"
+		}
+
+		return "boolean"
+	case config.FormFieldInt, config.FormFieldNumber:
+		return "number"
+	case config.FormFieldDecimal:
+		return "string | number"
+	case config.FormFieldString,
+		config.FormFieldURL,
+		config.FormFieldPhone,
+		config.FormFieldDate,
+		config.FormFieldDateTime:
+		return "string"
+	case config.FormFieldEnum:
+		values := quoteOptions(field)
+		if !field.Required {
+			values = append(values, `""`)
+		}
+
+		return strings.Join(values, " | ")
+	case config.FormFieldEnumList:
+		return "Array<" + strings.Join(quoteOptions(field), " | ") + ">"
+	case config.FormFieldObjects:
+		var b strings.Builder
+
+		keys := make([]string, 0, len(field.Shape))
+		for key := range field.Shape {
+			keys = append(keys, key)
+		}
+
+		slices.Sort(keys)
+
+		b.WriteString("Array<{\n")
+
+		for _, key := range keys {
+			sub := field.Shape[key]
+			writeProperty(&b, prefix+indent, key, &sub)
+		}
+
+		b.WriteString(prefix + "}>")
+
+		return b.String()
+	default:
+		return "unknown"
+	}
+}
+
+func quoteOptions(field *config.FormField) []string {
+	values := make([]string, 0, len(field.Options))
+	for _, option := range field.Options {
+		values = append(values, strconv.Quote(option.Value))
+	}
+
+	return values
+}
+
+func propertyName(name string) string {
+	if identifierPattern.MatchString(name) {
+		return name
+	}
+
+	return strconv.Quote(name)
+}
@@ -0,0 +1,405 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Bifröst client SDK.
+//
+// The SDK posts form submissions to Bifröst. It sends the token headers,
+// handles the time trap and proof-of-work challenges of the forms, and maps
+// the validation errors onto the form fields. Import it as a module from
+// the Bifröst server that the site uses:
+//
+//     import { createClient } from "https://bifrost.example/v1/sdk.js";
+//
+//     const client = createClient({ site: "example", token: "..." });
+//     client.enhance(document.querySelector("form"), { form: "contact" });
+//
+// The plain HTML forms keep working without JavaScript if their action points
+// to a page that handles them, and the SDK takes over the submits when it is
+// loaded.
+
+export const VERSION = "1.0.0";
+
+const SITE_TOKEN_HEADER = "X-Bifrost-Token";
+const FORM_TOKEN_HEADER = "X-Bifrost-Form-Token";
+const IDEMPOTENCY_KEY_HEADER = "Idempotency-Key";
+
+/**
+ * Error thrown when Bifröst rejects a submission.
+ */
+export class BifrostError extends Error {
+  /**
+   * @param {number} status HTTP status code of the response.
+   * @param {Array<{field: string, pointer: string, code: string, message: string}>} errors
+   *   Field-level validation errors, empty if the response had none.
+   * @param {string} message Error message.
+   */
+  constructor(status, errors, message) {
+    super(message);
+    this.name = "BifrostError";
+    this.status = status;
+    this.errors = errors;
+  }
+}
+
+/**
+ * Creates a client for the forms of a site.
+ *
+ * @param {object} options
+ * @param {string} options.site ID of the site.
+ * @param {string} [options.token] Site token, sent in the X-Bifrost-Token header.
+ * @param {string} [options.endpoint] Base URL of Bifröst. Defaults to the
+ *   origin that the SDK was loaded from.
+ * @param {Record<string, string>} [options.formTokens] Form tokens by form ID.
+ */
+export function createClient(options) {
+  const endpoint = (options.endpoint ?? new URL(import.meta.url).origin).replace(/\/+$/, "");
+  const formTokens = options.formTokens ?? {};
+
+  const formURL = (form) =>
+    `${endpoint}/v1/forms/${encodeURIComponent(options.site)}/${encodeURIComponent(form)}`;
+
+  const headers = (form) => {
+    const h = {};
+
+    if (options.token) {
+      h[SITE_TOKEN_HEADER] = options.token;
+    }
+
+    if (formTokens[form]) {
+      h[FORM_TOKEN_HEADER] = formTokens[form];
+    }
+
+    return h;
+  };
+
+  const getJSON = async (form, path) => {
+    const res = await fetch(formURL(form) + path, { headers: headers(form) });
+    if (!res.ok) {
+      throw new BifrostError(res.status, [], `request to ${path} failed with status ${res.status}`);
+    }
+
+    return res.json();
+  };
+
+  /**
+   * Fetches the signed timestamp of a form that has the time trap enabled.
+   * Fetch it when the form is shown as the submission is rejected if it is
+   * sent too soon after the timestamp was issued.
+   *
+   * @param {string} form ID of the form.
+   * @returns {Promise<{field: string, token: string}>}
+   */
+  const timestamp = (form) => getJSON(form, "/timestamp");
+
+  /**
+   * Fetches and solves the proof-of-work challenge of a form.
+   *
+   * @param {string} form ID of the form.
+   * @returns {Promise<{field: string, header: string, solution: string}>}
+   */
+  const proofOfWork = async (form) => {
+    const challenge = await getJSON(form, "/challenge");
+    const solution = await solve(challenge.challenge, challenge.difficulty);
+
+    return { field: challenge.field, header: challenge.header, solution };
+  };
+
+  /**
+   * Submits the payload of a form.
+   *
+   * @param {string} form ID of the form.
+   * @param {Record<string, unknown>} payload Payload of the submission.
+   * @param {object} [opts]
+   * @param {{field: string, token: string}} [opts.timestamp] Timestamp from
+   *   {@link timestamp} if the form has the time trap enabled.
+   * @param {boolean} [opts.proofOfWork] Solve the proof-of-work challenge
+   *   of the form before submitting.
+   * @param {string} [opts.idempotencyKey] Key that identifies the submission
+   *   when it is retried. Use the same key for every attempt of the same
+   *   submission. Without a key, the repeated submissions are detected by
+   *   their content.
+   * @returns {Promise<void>} Resolves when the submission is accepted.
+   */
+  const submit = async (form, payload, opts = {}) => {
+    const body = { ...payload };
+    const h = { ...headers(form), "Content-Type": "application/json" };
+
+    if (opts.idempotencyKey) {
+      h[IDEMPOTENCY_KEY_HEADER] = opts.idempotencyKey;
+    }
+
+    if (opts.timestamp) {
+      body[opts.timestamp.field] = opts.timestamp.token;
+    }
+
+    if (opts.proofOfWork) {
+      const pow = await proofOfWork(form);
+      h[pow.header] = pow.solution;
+    }
+
+    const res = await fetch(formURL(form), { method: "POST", headers: h, body: JSON.stringify(body) });
+    if (res.ok) {
+      return;
+    }
+
+    let errors = [];
+
+    if ((res.headers.get("Content-Type") ?? "").startsWith("application/json")) {
+      const data = await res.json().catch(() => ({}));
+      errors = Array.isArray(data.errors) ? data.errors : [];
+    }
+
+    const message = errors.length > 0 ? errors[0].message : `submission failed with status ${res.status}`;
+
+    throw new BifrostError(res.status, errors, message);
+  };
+
+  /**
+   * Takes over the submits of a plain HTML form. The values of the form are
+   * converted to the JSON types by the input types: checkboxes are booleans,
+   * or arrays if several checkboxes share a name, number inputs are numbers,
+   * and multiple selects are arrays. The type can be set explicitly with
+   * the data-bifrost-type attribute, which is one of "string", "number",
+   * "bool", or "list".
+   *
+   * The validation errors are set as the custom validity of the fields and
+   * written to the elements with a matching data-bifrost-error attribute.
+   * The submits are ignored while a submission is in flight, and the retries
+   * share an idempotency key until the submission is accepted.
+   *
+   * @param {HTMLFormElement} el The form element.
+   * @param {object} opts
+   * @param {string} opts.form ID of the form.
+   * @param {boolean} [opts.timeTrap] The form has the time trap enabled.
+   * @param {boolean} [opts.proofOfWork] The form has the proof of work enabled.
+   * @param {(el: HTMLFormElement) => void} [opts.onSuccess] Called when
+   *   the submission is accepted. By default, the form is reset and the
+   *   "bifrost-success" event is dispatched.
+   * @param {(err: Error, el: HTMLFormElement) => void} [opts.onError] Called
+   *   when the submission fails. By default, the "bifrost-error" event is
+   *   dispatched.
+   * @returns {() => void} Function that stops handling the submits.
+   */
+  const enhance = (el, opts) => {
+    let stamp = opts.timeTrap ? timestamp(opts.form).catch(() => undefined) : undefined;
+    let key = randomKey();
+    let busy = false;
+
+    const onSubmit = async (event) => {
+      event.preventDefault();
+
+      if (busy) {
+        return;
+      }
+
+      busy = true;
+      clearErrors(el);
+      el.setAttribute("aria-busy", "true");
+
+      try {
+        await submit(opts.form, formPayload(el), {
+          timestamp: await stamp,
+          proofOfWork: opts.proofOfWork,
+          idempotencyKey: key,
+        });
+
+        key = randomKey();
+
+        if (opts.onSuccess) {
+          opts.onSuccess(el);
+        } else {
+          el.reset();
+          el.dispatchEvent(new CustomEvent("bifrost-success", { bubbles: true }));
+        }
+
+        if (opts.timeTrap) {
+          stamp = timestamp(opts.form).catch(() => undefined);
+        }
+      } catch (err) {
+        if (err instanceof BifrostError) {
+          showErrors(el, err.errors);
+        }
+
+        if (opts.onError) {
+          opts.onError(err, el);
+        } else {
+          el.dispatchEvent(new CustomEvent("bifrost-error", { bubbles: true, detail: err }));
+        }
+      } finally {
+        busy = false;
+        el.removeAttribute("aria-busy");
+      }
+    };
+
+    el.addEventListener("submit", onSubmit);
+
+    return () => el.removeEventListener("submit", onSubmit);
+  };
+
+  return { submit, timestamp, proofOfWork, enhance };
+}
+
+/**
+ * Solves a proof-of-work challenge by finding a nonce so that the SHA-256
+ * hash of "<challenge>:<nonce>" has at least the given number of leading
+ * zero bits.
+ *
+ * @param {string} challenge The challenge.
+ * @param {number} difficulty Number of the leading zero bits.
+ * @returns {Promise<string>} The solution.
+ */
+export async function solve(challenge, difficulty) {
+  const encoder = new TextEncoder();
+
+  for (let nonce = 0; ; nonce++) {
+    const solution = `${challenge}:${nonce}`;
+    const sum = new Uint8Array(await crypto.subtle.digest("SHA-256", encoder.encode(solution)));
+
+    if (leadingZeros(sum) >= difficulty) {
+      return solution;
+    }
+  }
+}
+
+function leadingZeros(bytes) {
+  let n = 0;
+
+  for (const b of bytes) {
+    if (b === 0) {
+      n += 8;
+      continue;
+    }
+
+    return n + Math.clz32(b) - 24;
+  }
+
+  return n;
+}
+
+function randomKey() {
+  if (crypto.randomUUID) {
+    return crypto.randomUUID();
+  }
+
+  const bytes = crypto.getRandomValues(new Uint8Array(16));
+
+  return Array.from(bytes, (b) => b.toString(16).padStart(2, "0")).join("");
+}
+
+/**
+ * Converts the values of a form element to a JSON payload.
+ *
+ * @param {HTMLFormElement} el The form element.
+ * @returns {Record<string, unknown>}
+ */
+export function formPayload(el) {
+  const payload = {};
+  const counts = {};
+
+  for (const input of el.elements) {
+    if (input.name && input.type === "checkbox") {
+      counts[input.name] = (counts[input.name] ?? 0) + 1;
+    }
+  }
+
+  for (const input of el.elements) {
+    const name = input.name;
+    if (!name || input.disabled || ["submit", "button", "reset", "file"].includes(input.type)) {
+      continue;
+    }
+
+    const type = input.dataset.bifrostType ?? inferType(input, counts[name] ?? 0);
+
+    switch (type) {
+      case "bool":
+        payload[name] = input.checked;
+        break;
+      case "list":
+        payload[name] ??= [];
+
+        if (input.type === "select-multiple") {
+          payload[name].push(...Array.from(input.selectedOptions, (o) => o.value));
+        } else if (input.type !== "checkbox" || input.checked) {
+          payload[name].push(input.value);
+        }
+
+        break;
+      case "number":
+        if (input.value !== "") {
+          payload[name] = Number(input.value);
+        }
+
+        break;
+      default:
+        if (input.type === "radio") {
+          if (input.checked) {
+            payload[name] = input.value;
+          } else {
+            payload[name] ??= "";
+          }
+        } else {
+          payload[name] = input.value;
+        }
+    }
+  }
+
+  return payload;
+}
+
+function inferType(input, checkboxes) {
+  switch (input.type) {
+    case "checkbox":
+      return checkboxes > 1 ? "list" : "bool";
+    case "select-multiple":
+      return "list";
+    case "number":
+    case "range":
+      return "number";
+    default:
+      return "string";
+  }
+}
+
+function clearErrors(el) {
+  for (const input of el.elements) {
+    if (input.setCustomValidity) {
+      input.setCustomValidity("");
+      input.removeAttribute("aria-invalid");
+    }
+  }
+
+  for (const out of el.querySelectorAll("[data-bifrost-error]")) {
+    out.textContent = "";
+  }
+}
+
+function showErrors(el, errors) {
+  for (const error of errors) {
+    const inputs = Array.from(el.elements).filter((input) => input.name === error.field);
+
+    for (const input of inputs) {
+      input.setCustomValidity?.(error.message);
+      input.setAttribute("aria-invalid", "true");
+    }
+
+    for (const out of el.querySelectorAll("[data-bifrost-error]")) {
+      if (out.dataset.bifrostError === error.field || out.dataset.bifrostError === error.pointer) {
+        out.textContent = error.message;
+      }
+    }
+  }
+
+  const first = Array.from(el.elements).find((input) => input.getAttribute("aria-invalid") === "true");
+  first?.reportValidity?.();
+}
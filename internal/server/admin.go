@@ -0,0 +1,115 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/visiosto/bifrost/internal/config"
+	"github.com/visiosto/bifrost/internal/ipfilter"
+	"github.com/visiosto/bifrost/internal/metrics"
+	"github.com/visiosto/bifrost/internal/redact"
+	"github.com/visiosto/bifrost/internal/server/handlers"
+	"github.com/visiosto/bifrost/internal/storage"
+)
+
+func newAdminServer(
+	ctx context.Context,
+	cfg *config.Config,
+	m *metrics.Metrics,
+	red *redact.Redactor,
+	store storage.Store,
+	notifiers map[string]*handlers.Notifiers,
+	bans *ipfilter.Bans,
+) *http.Server {
+	if cfg.Admin.ListenAddr == "" {
+		if cfg.Admin.Metrics {
+			slog.WarnContext(ctx, "metrics are enabled but the admin listener is not configured")
+		}
+
+		return nil
+	}
+
+	if !cfg.Admin.Metrics && cfg.Admin.Token == "" {
+		slog.WarnContext(ctx, "admin listener is not started as neither metrics nor the admin token is configured")
+
+		return nil
+	}
+
+	mux := http.NewServeMux()
+
+	if cfg.Admin.Metrics {
+		slog.DebugContext(ctx, "registering metrics handler", "path", "/metrics")
+		mux.Handle("GET /metrics", m.Handler())
+	}
+
+	auth := func(h http.Handler) http.Handler {
+		return adminAuth(h, cfg.Admin.Token)
+	}
+
+	if cfg.Admin.Token != "" {
+		slog.DebugContext(ctx, "registering admin API handlers", "path", apiPrefix+"/bans")
+
+		mux.Handle("GET "+apiPrefix+"/bans", auth(handlers.ListBans(bans)))
+		mux.Handle("POST "+apiPrefix+"/bans", auth(handlers.CreateBan(bans)))
+		mux.Handle("DELETE "+apiPrefix+"/bans/{ip}", auth(handlers.DeleteBan(bans)))
+	}
+
+	switch {
+	case cfg.Admin.Token == "":
+		slog.InfoContext(ctx, "admin API is disabled as the admin token is not set")
+	case store == nil:
+		slog.InfoContext(ctx, "admin API for the submissions is disabled as the storage is not configured")
+	default:
+		slog.DebugContext(ctx, "registering admin API handlers", "path", apiPrefix+"/submissions")
+
+		mux.Handle("GET "+apiPrefix+"/submissions", auth(handlers.ListSubmissions(store)))
+		mux.Handle("DELETE "+apiPrefix+"/submissions", auth(handlers.EraseSubmissions(store)))
+		mux.Handle("GET "+apiPrefix+"/submissions/{id}", auth(handlers.GetSubmission(store)))
+		mux.Handle("POST "+apiPrefix+"/submissions/{id}/resend", auth(handlers.ResendSubmission(store, notifiers)))
+		mux.Handle("DELETE "+apiPrefix+"/submissions/{id}", auth(handlers.DeleteSubmission(store)))
+	}
+
+	return &http.Server{ //nolint:exhaustruct // use defaults
+		Addr:              cfg.Admin.ListenAddr,
+		Handler:           recoverer(requestID(accessLogger(mux, red))),
+		ReadTimeout:       5 * time.Second,  //nolint:mnd
+		WriteTimeout:      30 * time.Second, //nolint:mnd // resending may take a while
+		IdleTimeout:       10 * time.Second, //nolint:mnd
+		ReadHeaderTimeout: 2 * time.Second,  //nolint:mnd
+	}
+}
+
+// adminAuth requires the admin token as a bearer token in the Authorization
+// header.
+func adminAuth(h http.Handler, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			slog.WarnContext(r.Context(), "disallow admin request due to invalid token", "path", r.URL.Path)
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
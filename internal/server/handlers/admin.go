@@ -0,0 +1,292 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/visiosto/bifrost/internal/storage"
+)
+
+// Limits for listing the submissions.
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+var errInvalidQuery = errors.New("invalid query")
+
+// FormKey returns the key for the form of the given site in the maps of
+// per-form values.
+func FormKey(site, form string) string {
+	return site + "/" + form
+}
+
+// ListSubmissions is the admin handler for listing the stored submissions.
+// The submissions can be filtered with the "site", "form", "status", "verdict",
+// "from", "to", and "email" query parameters and paginated with "limit" and
+// "offset". Only the submissions with the "ham" verdict are listed by default
+// unless filtering by "email", which exports the data of a person for
+// an access request.
+func ListSubmissions(store storage.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		subs, err := store.List(r.Context(), filter)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to list submissions", "err", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+
+			return
+		}
+
+		writeJSON(w, r, http.StatusOK, map[string]any{"submissions": subs})
+	})
+}
+
+// GetSubmission is the admin handler for fetching a single submission.
+func GetSubmission(store storage.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sub, ok := loadSubmission(w, r, store)
+		if !ok {
+			return
+		}
+
+		writeJSON(w, r, http.StatusOK, sub)
+	})
+}
+
+// ResendSubmission is the admin handler for running the notifiers of a stored
+// submission again. The notifiers are looked up by [FormKey].
+func ResendSubmission(store storage.Store, notifiers map[string]*Notifiers) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sub, ok := loadSubmission(w, r, store)
+		if !ok {
+			return
+		}
+
+		n, ok := notifiers[FormKey(sub.Site, sub.Form)]
+		if !ok {
+			http.Error(w, "form of the submission is no longer configured", http.StatusConflict)
+
+			return
+		}
+
+		// The spam is sent again only to the quarantine so that resending
+		// does not deliver it to the real recipients.
+		var sendErr error
+		if sub.SpamVerdict == storage.VerdictSpam {
+			sendErr = n.SendSpam(r.Context(), sub.Payload)
+		} else {
+			sendErr = n.Send(r.Context(), sub.Payload)
+		}
+
+		sub.DeliveryStatus, sub.DeliveryError = storage.DeliverySent, ""
+		if sendErr != nil {
+			sub.DeliveryStatus, sub.DeliveryError = storage.DeliveryFailed, sendErr.Error()
+		}
+
+		err := store.UpdateDelivery(r.Context(), sub.ID, sub.DeliveryStatus, sub.DeliveryError)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to update submission delivery status", "id", sub.ID, "err", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+
+			return
+		}
+
+		slog.InfoContext(r.Context(), "resent submission", "id", sub.ID, "status", sub.DeliveryStatus)
+
+		status := http.StatusOK
+		if sendErr != nil {
+			status = http.StatusBadGateway
+		}
+
+		writeJSON(w, r, status, sub)
+	})
+}
+
+// EraseSubmissions is the admin handler for erasing all of the submissions
+// that contain the email address given in the "email" query parameter. It
+// responds with the number of the deleted submissions.
+func EraseSubmissions(store storage.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		email := r.URL.Query().Get("email")
+		if email == "" {
+			http.Error(w, "missing email", http.StatusBadRequest)
+
+			return
+		}
+
+		n, err := store.DeleteMatching(r.Context(), &storage.Filter{Value: email}) //nolint:exhaustruct // only by value
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to erase submissions", "err", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+
+			return
+		}
+
+		slog.InfoContext(r.Context(), "erased submissions by email", "count", n)
+		writeJSON(w, r, http.StatusOK, map[string]any{"deleted": n})
+	})
+}
+
+// DeleteSubmission is the admin handler for deleting a submission.
+func DeleteSubmission(store storage.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+
+			return
+		}
+
+		err = store.Delete(r.Context(), id)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "Not Found", http.StatusNotFound)
+
+			return
+		}
+
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to delete submission", "id", id, "err", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+
+			return
+		}
+
+		slog.InfoContext(r.Context(), "deleted submission", "id", id)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func loadSubmission(w http.ResponseWriter, r *http.Request, store storage.Store) (*storage.Submission, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+
+		return nil, false
+	}
+
+	sub, err := store.Get(r.Context(), id)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Not Found", http.StatusNotFound)
+
+		return nil, false
+	}
+
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to get submission", "id", id, "err", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+
+		return nil, false
+	}
+
+	return sub, true
+}
+
+func parseFilter(r *http.Request) (*storage.Filter, error) {
+	query := r.URL.Query()
+	filter := &storage.Filter{ //nolint:exhaustruct // filled below
+		Site:           query.Get("site"),
+		Form:           query.Get("form"),
+		DeliveryStatus: query.Get("status"),
+		Value:          query.Get("email"),
+		Limit:          defaultListLimit,
+	}
+
+	var err error
+
+	filter.From, err = parseTime(query.Get("from"))
+	if err != nil {
+		return nil, err
+	}
+
+	filter.To, err = parseTime(query.Get("to"))
+	if err != nil {
+		return nil, err
+	}
+
+	// The spam is listed only on request so that it does not end up with
+	// the real submissions, but the data of a person includes all of it.
+	switch verdict := query.Get("verdict"); verdict {
+	case "":
+		if filter.Value == "" {
+			filter.SpamVerdict = storage.VerdictHam
+		}
+	case storage.VerdictHam, storage.VerdictSpam:
+		filter.SpamVerdict = verdict
+	case "all":
+	default:
+		return nil, fmt.Errorf("%w: verdict must be ham, spam, or all", errInvalidQuery)
+	}
+
+	if s := query.Get("limit"); s != "" {
+		filter.Limit, err = strconv.Atoi(s)
+		if err != nil || filter.Limit <= 0 || filter.Limit > maxListLimit {
+			return nil, fmt.Errorf("%w: limit must be between 1 and %d", errInvalidQuery, maxListLimit)
+		}
+	}
+
+	if s := query.Get("offset"); s != "" {
+		filter.Offset, err = strconv.Atoi(s)
+		if err != nil || filter.Offset < 0 {
+			return nil, fmt.Errorf("%w: offset must be a non-negative integer", errInvalidQuery)
+		}
+	}
+
+	return filter, nil
+}
+
+// parseTime parses a time from a query parameter. Both RFC 3339 timestamps and
+// plain dates are accepted. An empty string results in the zero time.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err == nil {
+		return t, nil
+	}
+
+	t, err = time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid time %q", errInvalidQuery, s)
+	}
+
+	return t, nil
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to write response", "err", err)
+	}
+}
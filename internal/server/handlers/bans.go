@@ -0,0 +1,105 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/visiosto/bifrost/internal/config"
+	"github.com/visiosto/bifrost/internal/ipfilter"
+)
+
+const maxBanBodyBytes = 1 << 10
+
+type banRequest struct {
+	IP              string `json:"ip"`
+	Reason          string `json:"reason"`
+	DurationSeconds int    `json:"durationSeconds"`
+}
+
+// ListBans is the admin handler for listing the current IP address bans.
+func ListBans(bans *ipfilter.Bans) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, r, http.StatusOK, map[string]any{"bans": bans.List()})
+	})
+}
+
+// CreateBan is the admin handler for banning an IP address manually.
+func CreateBan(bans *ipfilter.Bans) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req banRequest
+
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBanBodyBytes)).Decode(&req)
+		if err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+
+			return
+		}
+
+		addr, err := netip.ParseAddr(req.IP)
+		if err != nil {
+			http.Error(w, "invalid IP address", http.StatusBadRequest)
+
+			return
+		}
+
+		if req.DurationSeconds <= 0 {
+			http.Error(w, "durationSeconds must be greater than zero", http.StatusBadRequest)
+
+			return
+		}
+
+		if req.DurationSeconds > config.MaxBanDurationSeconds {
+			http.Error(w, "durationSeconds must be at most "+strconv.Itoa(config.MaxBanDurationSeconds), http.StatusBadRequest)
+
+			return
+		}
+
+		if req.Reason == "" {
+			req.Reason = "manual"
+		}
+
+		ban := bans.Ban(addr, time.Duration(req.DurationSeconds)*time.Second, req.Reason)
+
+		slog.InfoContext(r.Context(), "banned IP address manually", "until", ban.Until, "reason", ban.Reason)
+		writeJSON(w, r, http.StatusCreated, ban)
+	})
+}
+
+// DeleteBan is the admin handler for lifting the ban of an IP address.
+func DeleteBan(bans *ipfilter.Bans) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr, err := netip.ParseAddr(r.PathValue("ip"))
+		if err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+
+			return
+		}
+
+		if !bans.Unban(addr) {
+			http.Error(w, "Not Found", http.StatusNotFound)
+
+			return
+		}
+
+		slog.InfoContext(r.Context(), "lifted IP address ban")
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,371 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nyaruka/phonenumbers"
+	"github.com/rivo/uniseg"
+	"github.com/visiosto/bifrost/internal/config"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Reasons for rejecting the values of the formatted fields.
+const (
+	reasonInvalidPattern = "invalid_pattern"
+	reasonInvalidURL     = "invalid_url"
+	reasonInvalidPhone   = "invalid_phone"
+	reasonInvalidDate    = "invalid_date"
+	reasonInvalidNumber  = "invalid_number"
+)
+
+// maxSafeInteger is the largest integer that JSON numbers can represent
+// exactly as they are decoded to float64.
+const maxSafeInteger = 1<<53 - 1
+
+// errFieldConfig is returned if the value cannot be validated because of
+// the config of the field. It is a server error and not a client error.
+var errFieldConfig = errors.New("invalid field config")
+
+var decimalPattern = regexp.MustCompile(`^[+-]?\d+(\.\d+)?$`)
+
+// acceptsString reports whether the fields of the type have string values in
+// the payload.
+func acceptsString(t config.FormFieldType) bool {
+	switch t { //nolint:exhaustive // the rest do not accept strings
+	case config.FormFieldString,
+		config.FormFieldEnum,
+		config.FormFieldURL,
+		config.FormFieldPhone,
+		config.FormFieldDate,
+		config.FormFieldDateTime,
+		config.FormFieldDecimal:
+		return true
+	default:
+		return false
+	}
+}
+
+// acceptsNumber reports whether the fields of the type have number values in
+// the payload.
+func acceptsNumber(t config.FormFieldType) bool {
+	return t == config.FormFieldInt || t == config.FormFieldNumber || t == config.FormFieldDecimal
+}
+
+// sanitizeText strips the control characters from the value of a text field,
+// normalizes it, and trims the surrounding whitespace unless the field
+// disables them.
+func sanitizeText(field *config.FormField, s string) string {
+	if !field.KeepControlChars {
+		s = strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+				return -1
+			}
+
+			return r
+		}, s)
+	}
+
+	switch field.Normalization {
+	case config.NormalizationNFC:
+		s = norm.NFC.String(s)
+	case config.NormalizationNFKC:
+		s = norm.NFKC.String(s)
+	}
+
+	if !field.KeepWhitespace {
+		s = strings.TrimSpace(s)
+	}
+
+	return s
+}
+
+// validateLength checks the length of the value of a text field in the length
+// unit of the field.
+func validateLength(name string, field *config.FormField, s string) error {
+	if field.Min == nil && field.Max == nil {
+		return nil
+	}
+
+	var n int
+
+	unit := "characters"
+
+	switch field.LengthUnit {
+	case config.LengthGraphemes:
+		n = uniseg.GraphemeClusterCount(s)
+	case config.LengthBytes:
+		n = len(s)
+		unit = "bytes"
+	default:
+		n = utf8.RuneCountInString(s)
+	}
+
+	if !inCountBounds(field, n) {
+		return &payloadError{
+			field:   name,
+			reason:  reasonOutOfRange,
+			message: fmt.Sprintf("field %q must be %s %s but it is %d %s", name, describeBounds(field), unit, n, unit),
+		}
+	}
+
+	return nil
+}
+
+// inCountBounds reports whether the length or the number of the elements is
+// within the bounds of the field.
+func inCountBounds(field *config.FormField, n int) bool {
+	if n < field.MinInt() {
+		return false
+	}
+
+	maxCount, ok := field.MaxInt()
+
+	return !ok || n <= maxCount
+}
+
+// describeBounds describes the bounds of the field for the error messages.
+func describeBounds(field *config.FormField) string {
+	switch {
+	case field.Min != nil && field.Max != nil:
+		return fmt.Sprintf("between %s and %s", formatNumber(*field.Min), formatNumber(*field.Max))
+	case field.Min != nil:
+		return "at least " + formatNumber(*field.Min)
+	case field.Max != nil:
+		return "at most " + formatNumber(*field.Max)
+	default:
+		return "anything"
+	}
+}
+
+// validateFormatted validates the value of a URL, phone, date, or datetime
+// field and returns the normalized value. The empty values are checked by the
+// caller.
+func validateFormatted(name string, field *config.FormField, s string) (any, error) {
+	if re := field.Regexp(); re != nil && !re.MatchString(s) {
+		return nil, &payloadError{
+			field:   name,
+			reason:  reasonInvalidPattern,
+			message: fmt.Sprintf("field %q does not match the pattern %q", name, re.String()),
+		}
+	}
+
+	switch field.Type { //nolint:exhaustive // only the formatted types are validated here
+	case config.FormFieldURL:
+		return validateURL(name, s)
+	case config.FormFieldPhone:
+		return validatePhone(name, field, s)
+	case config.FormFieldDate, config.FormFieldDateTime:
+		return validateDate(name, field, s, time.Now())
+	default:
+		return s, nil
+	}
+}
+
+func validateURL(name, s string) (any, error) {
+	u, err := url.ParseRequestURI(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, &payloadError{
+			field:   name,
+			reason:  reasonInvalidURL,
+			message: fmt.Sprintf("field %q is not a valid http or https URL", name),
+		}
+	}
+
+	return u.String(), nil
+}
+
+// validatePhone validates the phone number and normalizes it to the E.164
+// format.
+func validatePhone(name string, field *config.FormField, s string) (any, error) {
+	num, err := phonenumbers.Parse(s, field.DefaultCountry)
+	if err != nil || !phonenumbers.IsValidNumber(num) {
+		return nil, &payloadError{
+			field:   name,
+			reason:  reasonInvalidPhone,
+			message: fmt.Sprintf("field %q is not a valid phone number", name),
+		}
+	}
+
+	return phonenumbers.Format(num, phonenumbers.E164), nil
+}
+
+// validateDate validates the date or datetime value against the bounds of
+// the field. The dates are kept as they are and the datetimes are normalized
+// to RFC 3339.
+func validateDate(name string, field *config.FormField, s string, now time.Time) (any, error) {
+	var (
+		t   time.Time
+		err error
+	)
+
+	if field.Type == config.FormFieldDate {
+		t, err = time.ParseInLocation(config.DateLayout, s, field.Location())
+	} else {
+		t, err = config.ParseDateTime(s, field.Location())
+	}
+
+	if err != nil {
+		return nil, &payloadError{
+			field:   name,
+			reason:  reasonInvalidDate,
+			message: fmt.Sprintf("field %q is not a valid %s", name, field.Type),
+		}
+	}
+
+	minDate, maxDate, err := field.DateBounds(now)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to resolve the date bounds of field %q: %w", errFieldConfig, name, err)
+	}
+
+	// The date bounds are compared by the day so that "today" includes
+	// the whole day.
+	if field.Type == config.FormFieldDate {
+		minDate = truncateDay(minDate)
+		maxDate = truncateDay(maxDate)
+	}
+
+	if (!minDate.IsZero() && t.Before(minDate)) || (!maxDate.IsZero() && t.After(maxDate)) {
+		return nil, &payloadError{
+			field:   name,
+			reason:  reasonOutOfRange,
+			message: fmt.Sprintf("field %q must be between %q and %q", name, field.MinDate, field.MaxDate),
+		}
+	}
+
+	if field.Type == config.FormFieldDate {
+		return s, nil
+	}
+
+	return t.Format(time.RFC3339), nil
+}
+
+func truncateDay(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// validateInt checks that the number is a whole number within the bounds of
+// the field and returns it as an int. The number must also be exactly
+// representable so that large numbers are not silently rounded.
+func validateInt(name string, field *config.FormField, f float64) (int, error) {
+	if f != math.Trunc(f) {
+		return 0, &payloadError{
+			field:   name,
+			reason:  reasonInvalidNumber,
+			message: fmt.Sprintf("field %q must be a whole number but it is %s", name, formatNumber(f)),
+		}
+	}
+
+	if math.Abs(f) > maxSafeInteger {
+		return 0, &payloadError{
+			field:   name,
+			reason:  reasonOutOfRange,
+			message: fmt.Sprintf("field %q is too large to be a whole number", name),
+		}
+	}
+
+	err := validateNumber(name, field, f)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(f), nil
+}
+
+// validateNumber checks the number against the bounds and the precision of
+// the field.
+func validateNumber(name string, field *config.FormField, f float64) error {
+	if (field.Min != nil && f < *field.Min) || (field.Max != nil && f > *field.Max) {
+		return &payloadError{
+			field:   name,
+			reason:  reasonOutOfRange,
+			message: fmt.Sprintf("field %q must be %s but it is %s", name, describeBounds(field), formatNumber(f)),
+		}
+	}
+
+	return validatePrecision(name, field, formatNumber(f))
+}
+
+// validateDecimal validates the decimal number and normalizes it to a string
+// so that no precision is lost.
+func validateDecimal(name string, field *config.FormField, s string) (any, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || !decimalPattern.MatchString(s) {
+		return nil, &payloadError{
+			field:   name,
+			reason:  reasonInvalidNumber,
+			message: fmt.Sprintf("field %q is not a valid decimal number", name),
+		}
+	}
+
+	if (field.Min != nil && r.Cmp(new(big.Rat).SetFloat64(*field.Min)) < 0) ||
+		(field.Max != nil && r.Cmp(new(big.Rat).SetFloat64(*field.Max)) > 0) {
+		return nil, &payloadError{
+			field:   name,
+			reason:  reasonOutOfRange,
+			message: fmt.Sprintf("field %q must be %s but it is %s", name, describeBounds(field), s),
+		}
+	}
+
+	err := validatePrecision(name, field, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// validatePrecision checks the number of the decimal places in the formatted
+// number against the precision of the field.
+func validatePrecision(name string, field *config.FormField, s string) error {
+	if field.Precision == nil {
+		return nil
+	}
+
+	places := 0
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		places = len(s) - i - 1
+	}
+
+	if places > *field.Precision {
+		return &payloadError{
+			field:   name,
+			reason:  reasonInvalidNumber,
+			message: fmt.Sprintf("field %q can have at most %d decimal places", name, *field.Precision),
+		}
+	}
+
+	return nil
+}
+
+// formatNumber formats a JSON number without an exponent.
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
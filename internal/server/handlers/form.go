@@ -15,145 +15,67 @@
 package handlers
 
 import (
-	"bytes"
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"html/template"
 	"log/slog"
+	"math/bits"
 	"net/http"
 	"strconv"
 	"strings"
-	texttemplate "text/template"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	awsconfig "github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/ses"
-	"github.com/aws/aws-sdk-go-v2/service/ses/types"
+	"github.com/visiosto/bifrost/internal/captcha"
+	"github.com/visiosto/bifrost/internal/client"
 	"github.com/visiosto/bifrost/internal/config"
+	"github.com/visiosto/bifrost/internal/i18n"
+	"github.com/visiosto/bifrost/internal/logging"
+	"github.com/visiosto/bifrost/internal/pow"
+	"github.com/visiosto/bifrost/internal/schema"
+	"github.com/visiosto/bifrost/internal/spam"
+	"github.com/visiosto/bifrost/internal/storage"
+	"github.com/visiosto/bifrost/internal/timetrap"
+	"github.com/visiosto/bifrost/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-//
-//nolint:lll
-const htmlTemplate = `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
-<html lang="{{.lang}}">
-<head>
-<meta charset="utf-8" />
-<meta http-equiv="Content-Type" content="text/html; charset=UTF-8" />
-<title>{{- .subject -}}</title>
-</head>
-<body>
-	<h1>{{.subject}}</h1>
-	{{if (ne .intro "") -}}
-		<p style="font-size: 14px; line-height: 24px; margin: 16px 0">
-			{{- .intro -}}
-		</p>
-	{{end -}}
-	{{$fields := .fields -}}
-	{{$objs := .objs -}}
-	{{$payload := .payload -}}
-	{{$hidden := .hidden -}}
-	{{if (gt (len .order) 0) -}}
-		{{range $key := .order -}}
-			{{$field := index $fields $key -}}
-			{{if (IsObj $key)}}
-				{{$lines := index $objs $key -}}
-				{{if (gt (len $lines) 0) -}}
-					<h2>{{if (eq $field.DisplayName "")}}{{$key}}{{else}}{{$field.DisplayName}}{{end}}</h2>
-					<ul style="font-size: 14px; line-height: 24px; margin: 16px 0">
-						{{range $line := $lines}}
-							<li>{{- $line -}}</li>
-						{{end}}
-					</ul>
-				{{end -}}
-			{{ else -}}
-				<h2>{{if (eq $field.DisplayName "")}}{{$key}}{{else}}{{$field.DisplayName}}{{end}}</h2>
-				<p style="font-size: 14px; line-height: 24px; margin: 16px 0">
-					{{- index $payload $key -}}
-				</p>
-			{{end -}}
-		{{end -}}
-	{{else -}}
-		{{range $key, $value := $payload -}}
-			{{$hide := false}}
-			{{range $k := $hidden}}{{if (eq $k $key)}}{{$hide = true}}{{end}}{{end}}
-			{{if $hide}}{{continue}}{{end}}
-			{{$field := index $fields $key -}}
-			<h2>{{if (eq $field.DisplayName "")}}{{$key}}{{else}}{{$field.DisplayName}}{{end}}</h2>
-			{{if (IsObj $key)}}
-				{{$lines := index $objs $key -}}
-				<ul style="font-size: 14px; line-height: 24px; margin: 16px 0">
-					{{range $line := $lines}}
-						<li>{{- $line -}}</li>
-					{{end}}
-				</ul>
-			{{ else -}}
-				<p style="font-size: 14px; line-height: 24px; margin: 16px 0">
-					{{- $value -}}
-				</p>
-			{{end -}}
-		{{end -}}
-	{{end -}}
-</body>
-</html>
-`
-
-const textTemplate = `{{- if (ne .intro "") -}}{{.intro}}{{- end}}
-{{$fields := .fields -}}
-{{$objs := .objs -}}
-{{$payload := .payload -}}
-{{$hidden := .hidden -}}
-{{if (gt (len .order) 0) -}}
-	{{range $key := .order -}}
-		{{$field := index $fields $key -}}
-		{{if (IsObj $key)}}
-			{{$lines := index $objs $key -}}
-			{{if (gt (len $lines) 0) -}}
-				{{if (eq $field.DisplayName "") -}}{{$key}}{{else -}}{{$field.DisplayName}}{{end}}:
-				{{range $line := $lines}}
-				  - {{$line -}}
-				{{end}}
-			{{end -}}
-		{{ else -}}
-			{{if (eq $field.DisplayName "") -}}{{$key}}{{else -}}{{$field.DisplayName}}{{end}}: {{index $payload $key}}
-		{{end -}}
-	{{end -}}
-{{else -}}
-	{{range $key, $value := $payload -}}
-		{{$hide := false -}}
-		{{range $k := $hidden -}}{{if (eq $k $key) -}}{{$hide = true -}}{{end -}}{{end -}}
-		{{if $hide -}}{{continue -}}{{end -}}
-		{{$field := index $fields $key -}}
-		{{if (IsObj $key)}}
-			{{$lines := index $objs $key -}}
-			{{if (eq $field.DisplayName "") -}}{{$key}}{{else -}}{{$field.DisplayName}}{{end}}:
-			{{range $line := $lines}}
-			  - {{$line -}}
-			{{end}}
-		{{ else -}}
-			{{if (eq $field.DisplayName "") -}}{{$key}}{{else -}}{{$field.DisplayName}}{{end}}: {{$value}}
-		{{end -}}
-	{{end -}}
-{{end}}
-`
+const tracerName = "github.com/visiosto/bifrost/internal/server/handlers"
+
+// Reasons for rejecting a form payload.
+const (
+	reasonInvalidJSON     = "invalid_json"
+	reasonMultipleObjects = "multiple_objects"
+	reasonUnknownField    = "unknown_field"
+	reasonInvalidType     = "invalid_type"
+	reasonRequired        = "required"
+	reasonOutOfRange      = "out_of_range"
+	reasonInvalidObject   = "invalid_object"
+	reasonInvalidOption   = "invalid_option"
+	reasonCaptcha         = "captcha"
+	reasonTimeTrap        = "time_trap"
+	reasonProofOfWork     = "proof_of_work"
+	reasonOther           = "other"
+)
 
 type honeypotError struct {
 	message string
 }
 
 type payloadError struct {
-	field   string
+	field string
+
+	// pointer is the JSON pointer of the invalid value if it is nested in
+	// the field. Otherwise, the value of the field is invalid.
+	pointer string
+	reason  string
 	message string
 }
 
-type sesTemplate struct {
-	subject *texttemplate.Template
-	intro   *texttemplate.Template
-	html    *template.Template
-	text    *texttemplate.Template
-	cfg     *config.SESNotifier
-	objs    map[string]*texttemplate.Template
+// statusWriter records the status code of the response.
+type statusWriter struct {
+	http.ResponseWriter
+
+	status int
 }
 
 func (e *honeypotError) Error() string {
@@ -164,16 +86,36 @@ func (e *payloadError) Error() string {
 	return e.message
 }
 
+func (w *statusWriter) WriteHeader(statusCode int) {
+	w.status = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
 // FormPreflight is the handler for the `OPTIONS` method of form endpoints.
 func FormPreflight(form *config.Form) http.Handler {
+	return preflight(form, "POST, OPTIONS")
+}
+
+// FormGetPreflight is the handler for the `OPTIONS` method of the endpoints
+// that the form fetches the time trap timestamps and the proof-of-work
+// challenges from.
+func FormGetPreflight(form *config.Form) http.Handler {
+	return preflight(form, "GET, OPTIONS")
+}
+
+func preflight(form *config.Form, methods string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", methods)
 
-		allowHeaders := []string{"Content-Type", config.SiteTokenHeader}
+		allowHeaders := []string{"Content-Type", config.SiteTokenHeader, IdempotencyKeyHeader}
 		if form.Token != "" {
 			allowHeaders = append(allowHeaders, config.FormTokenHeader)
 		}
 
+		if form.ProofOfWork != nil {
+			allowHeaders = append(allowHeaders, config.PowHeader)
+		}
+
 		w.Header().Set("Access-Control-Allow-Headers", strings.Join(allowHeaders, ", "))
 		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(form.AccessControlMaxAge))
 
@@ -185,24 +127,49 @@ func FormPreflight(form *config.Form) http.Handler {
 }
 
 // SubmitForm returns a [http.Handler] for a form endpoint.
-func SubmitForm(site *config.Site, form *config.Form) (http.Handler, error) { //nolint:funlen // TODO: clean up
-	sesTmpls, err := createSMTPTemplates(form)
-	if err != nil {
-		return nil, err
+//
+//nolint:funlen // TODO: clean up
+func SubmitForm(site *config.Site, form *config.Form, notifiers *Notifiers, deps *Deps) http.Handler {
+	tracer := tracing.Tracer(tracerName)
+
+	var verifier *captcha.Verifier
+	if form.Captcha != nil {
+		verifier = captcha.New(site, form.Captcha)
+	}
+
+	var signer *timetrap.Signer
+	if form.TimeTrap != nil {
+		signer = timetrap.NewSigner(site.TimeTrapSecret)
+	}
+
+	var challenger *pow.Challenger
+	if form.ProofOfWork != nil {
+		challenger = newChallenger(site, form)
+	}
+
+	var filter *spam.Engine
+	if form.Spam != nil {
+		filter = spam.New(form.Spam)
 	}
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		payload := map[string]any{}
 		dec := json.NewDecoder(r.Body)
 
+		_, decodeSpan := tracer.Start(r.Context(), "decode payload")
 		err := dec.Decode(&payload)
+
+		decodeSpan.End()
+
 		if err != nil {
-			http.Error(w, "Bad Request", http.StatusBadRequest)
+			deps.Metrics.ValidationFailure(site.ID, form.ID, reasonInvalidJSON)
+			writeError(w, r, form, http.StatusBadRequest, reasonInvalidJSON)
 
 			return
 		}
 
 		if dec.More() {
+			deps.Metrics.ValidationFailure(site.ID, form.ID, reasonMultipleObjects)
 			slog.WarnContext(
 				r.Context(),
 				"reject request with more than one JSON object",
@@ -213,7 +180,7 @@ func SubmitForm(site *config.Site, form *config.Form) (http.Handler, error) { //
 				"form",
 				form.ID,
 			)
-			http.Error(w, "Bad Request", http.StatusBadRequest)
+			writeError(w, r, form, http.StatusBadRequest, reasonMultipleObjects)
 
 			return
 		}
@@ -232,7 +199,33 @@ func SubmitForm(site *config.Site, form *config.Form) (http.Handler, error) { //
 		// 	payload,
 		// )
 
+		// The CAPTCHA token is not part of the form data so it is removed
+		// before the validation.
+		var (
+			captchaToken, timestamp any
+			powSolution             string
+		)
+
+		if form.Captcha != nil {
+			captchaToken = payload[form.Captcha.TokenField]
+			delete(payload, form.Captcha.TokenField)
+		}
+
+		if form.TimeTrap != nil {
+			timestamp = payload[form.TimeTrap.Field]
+			delete(payload, form.TimeTrap.Field)
+		}
+
+		if form.ProofOfWork != nil {
+			powSolution = proofOfWorkSolution(r, payload[form.ProofOfWork.Field])
+			delete(payload, form.ProofOfWork.Field)
+		}
+
+		_, validateSpan := tracer.Start(r.Context(), "validate payload")
 		err = validatePayload(form, payload)
+
+		validateSpan.End()
+
 		if err != nil { //nolint:nestif // TODO: clean up
 			var (
 				honeypotErr *honeypotError
@@ -240,6 +233,8 @@ func SubmitForm(site *config.Site, form *config.Form) (http.Handler, error) { //
 			)
 
 			if errors.As(err, &honeypotErr) {
+				deps.Metrics.HoneypotHit(site.ID, form.ID)
+				strike(r, site, "honeypot", deps)
 				slog.WarnContext(
 					r.Context(),
 					"request contained the honeypot field",
@@ -253,32 +248,13 @@ func SubmitForm(site *config.Site, form *config.Form) (http.Handler, error) { //
 					honeypotErr.Error(),
 				)
 
-				// Show the request as a success to not tip off bots.
-				w.WriteHeader(http.StatusResetContent)
-
-				_, err = w.Write([]byte("accepted"))
-				if err != nil {
-					slog.ErrorContext(
-						r.Context(),
-						"failed write response",
-						"path",
-						r.URL.Path,
-						"site",
-						site.ID,
-						"form",
-						form.ID,
-						"err",
-						err.Error(),
-					)
-					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-
-					return
-				}
+				writeFakeSuccess(w, r, site, form)
 
 				return
 			}
 
 			if errors.As(err, &payloadErr) {
+				deps.Metrics.ValidationFailure(site.ID, form.ID, payloadErr.reason)
 				slog.WarnContext(
 					r.Context(),
 					"invalid request payload",
@@ -293,10 +269,16 @@ func SubmitForm(site *config.Site, form *config.Form) (http.Handler, error) { //
 					"err",
 					err.Error(),
 				)
-			} else {
-				slog.WarnContext(
+
+				writePayloadError(w, r, form, payloadErr)
+
+				return
+			}
+
+			if errors.Is(err, errFieldConfig) {
+				slog.ErrorContext(
 					r.Context(),
-					"invalid request payload",
+					"failed to validate request payload",
 					"path",
 					r.URL.Path,
 					"site",
@@ -306,18 +288,15 @@ func SubmitForm(site *config.Site, form *config.Form) (http.Handler, error) { //
 					"err",
 					err.Error(),
 				)
-			}
+				writeError(w, r, form, http.StatusInternalServerError, i18n.CodeInternalError)
 
-			http.Error(w, "Bad Request", http.StatusBadRequest)
-
-			return
-		}
+				return
+			}
 
-		err = handleSESNotifiers(w, r, form, sesTmpls, payload)
-		if err != nil {
-			slog.ErrorContext(
+			deps.Metrics.ValidationFailure(site.ID, form.ID, reasonOther)
+			slog.WarnContext(
 				r.Context(),
-				"failed to send SES notifications",
+				"invalid request payload",
 				"path",
 				r.URL.Path,
 				"site",
@@ -327,18 +306,63 @@ func SubmitForm(site *config.Site, form *config.Form) (http.Handler, error) { //
 				"err",
 				err.Error(),
 			)
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 
+			writeError(w, r, form, http.StatusBadRequest, reasonOther)
+
+			return
+		}
+
+		if signer != nil && !checkTimeTrap(w, r, site, form, signer, timestamp, deps) {
 			return
 		}
 
-		w.WriteHeader(http.StatusResetContent)
+		if challenger != nil {
+			if !checkProofOfWork(w, r, site, form, challenger, powSolution, deps) {
+				return
+			}
+
+			// The solution is released if the submission fails because of
+			// the server so that the client can retry it.
+			sw := &statusWriter{ResponseWriter: w, status: 0}
+			w = sw
+
+			defer func() {
+				if sw.status >= http.StatusInternalServerError {
+					challenger.Release(powSolution)
+				}
+			}()
+		}
+
+		if verifier != nil && !verifyCaptcha(w, r, site, form, verifier, captchaToken, deps) {
+			return
+		}
+
+		verdict := storage.VerdictHam
+
+		if filter != nil && isSpam(r, site, form, filter, payload, deps) {
+			if form.Spam.Action == config.SpamDrop {
+				writeFakeSuccess(w, r, site, form)
+
+				return
+			}
+
+			verdict = storage.VerdictSpam
+		}
+
+		sub := saveSubmission(r, site, form, payload, verdict, deps)
+
+		if verdict == storage.VerdictSpam {
+			err = notifiers.SendSpam(r.Context(), payload)
+		} else {
+			err = notifiers.Send(r.Context(), payload)
+		}
+
+		updateDelivery(r, sub, err, deps)
 
-		_, err = w.Write([]byte("accepted"))
 		if err != nil {
 			slog.ErrorContext(
 				r.Context(),
-				"failed write response",
+				"failed to send SES notifications",
 				"path",
 				r.URL.Path,
 				"site",
@@ -348,23 +372,394 @@ func SubmitForm(site *config.Site, form *config.Form) (http.Handler, error) { //
 				"err",
 				err.Error(),
 			)
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			writeError(w, r, form, http.StatusInternalServerError, i18n.CodeInternalError)
 
 			return
 		}
-	}), nil
+
+		writeAccepted(w, r, site, form)
+	})
 }
 
-//nolint:cyclop,funlen,gocognit,gocyclo,maintidx // let's keep this as one function
-func validatePayload(form *config.Form, payload map[string]any) error {
-	seenKeys := map[string]struct{}{}
+// FormTimestamp returns a [http.Handler] that issues the signed timestamps
+// for the time trap of the form.
+func FormTimestamp(site *config.Site, form *config.Form) http.Handler {
+	signer := timetrap.NewSigner(site.TimeTrapSecret)
 
-	for k, v := range payload { //nolint:varnamelen // standard naming
-		seenKeys[k] = struct{}{}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, r, http.StatusOK, map[string]string{
+			"field": form.TimeTrap.Field,
+			"token": signer.Issue(site.ID, form.ID, time.Now()),
+		})
+	})
+}
+
+// FormSchema is the handler for the `GET` method of the schema endpoint of
+// a form. It responds with the JSON Schema of the payload of the form.
+func FormSchema(form *config.Form) http.Handler {
+	formSchema := schema.JSONSchema(form)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/schema+json")
+		w.WriteHeader(http.StatusOK)
+
+		err := json.NewEncoder(w).Encode(formSchema)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to write response", "err", err)
+		}
+	})
+}
+
+// checkTimeTrap verifies the signed timestamp of the submission. It writes
+// the response and returns false if the submission must not be accepted.
+func checkTimeTrap(
+	w http.ResponseWriter,
+	r *http.Request,
+	site *config.Site,
+	form *config.Form,
+	signer *timetrap.Signer,
+	timestamp any,
+	deps *Deps,
+) bool {
+	// A token of the wrong type is treated as malformed.
+	token, _ := timestamp.(string)
+
+	err := signer.Verify(
+		token,
+		site.ID,
+		form.ID,
+		time.Now(),
+		time.Duration(form.TimeTrap.MinAgeSeconds)*time.Second,
+		time.Duration(form.TimeTrap.MaxAgeSeconds)*time.Second,
+	)
+	if err == nil {
+		return true
+	}
+
+	deps.Metrics.ValidationFailure(site.ID, form.ID, reasonTimeTrap)
+	slog.WarnContext(
+		r.Context(),
+		"request failed the time trap",
+		"site",
+		site.ID,
+		"form",
+		form.ID,
+		"action",
+		form.TimeTrap.Action,
+		"err",
+		err.Error(),
+	)
+
+	if form.TimeTrap.Action == config.TimeTrapReject {
+		writeError(w, r, form, http.StatusBadRequest, reasonTimeTrap)
+
+		return false
+	}
+
+	writeFakeSuccess(w, r, site, form)
+
+	return false
+}
+
+// FormChallenge returns a [http.Handler] that issues the proof-of-work
+// challenges of the form.
+func FormChallenge(site *config.Site, form *config.Form, deps *Deps) http.Handler {
+	challenger := newChallenger(site, form)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		difficulty := form.ProofOfWork.Difficulty
+
+		// Each doubling of the recent requests adds a bit to the difficulty.
+		// The request for the challenge itself is not counted.
+		if form.ProofOfWork.ScaleWithRate && deps.RecentRequests != nil {
+			n := max(deps.RecentRequests(site.ID, client.IP(r))-1, 0)
+			difficulty = min(difficulty+bits.Len(uint(n)), form.ProofOfWork.MaxDifficulty) //nolint:gosec // n is not negative
+		}
+
+		challenge, err := challenger.Issue(difficulty, time.Now())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to issue challenge", "site", site.ID, "form", form.ID, "err", err)
+			writeError(w, r, form, http.StatusInternalServerError, i18n.CodeInternalError)
+
+			return
+		}
+
+		writeJSON(w, r, http.StatusOK, map[string]any{
+			"algorithm":  pow.Algorithm,
+			"challenge":  challenge,
+			"difficulty": difficulty,
+			"field":      form.ProofOfWork.Field,
+			"header":     config.PowHeader,
+		})
+	})
+}
+
+func newChallenger(site *config.Site, form *config.Form) *pow.Challenger {
+	return pow.NewChallenger(
+		site.PowSecret,
+		site.ID,
+		form.ID,
+		time.Duration(form.ProofOfWork.MaxAgeSeconds)*time.Second,
+	)
+}
+
+// proofOfWorkSolution returns the proof-of-work solution of the submission
+// from the header or the payload field.
+func proofOfWorkSolution(r *http.Request, field any) string {
+	solution := r.Header.Get(config.PowHeader)
+	if solution == "" {
+		// A solution of the wrong type is treated as malformed.
+		solution, _ = field.(string)
+	}
+
+	return solution
+}
+
+// checkProofOfWork verifies the proof-of-work solution of the submission. It
+// writes the error response and returns false if the submission must not be
+// accepted.
+func checkProofOfWork(
+	w http.ResponseWriter,
+	r *http.Request,
+	site *config.Site,
+	form *config.Form,
+	challenger *pow.Challenger,
+	solution string,
+	deps *Deps,
+) bool {
+	err := challenger.Verify(solution, form.ProofOfWork.Difficulty, time.Now())
+	if err == nil {
+		return true
+	}
+
+	deps.Metrics.ValidationFailure(site.ID, form.ID, reasonProofOfWork)
+	slog.WarnContext(
+		r.Context(),
+		"reject request with invalid proof of work",
+		"site",
+		site.ID,
+		"form",
+		form.ID,
+		"err",
+		err.Error(),
+	)
+	writeError(w, r, form, http.StatusForbidden, reasonProofOfWork)
+
+	return false
+}
+
+// isSpam scores the content of the submission and reports whether it is
+// spam.
+func isSpam(
+	r *http.Request,
+	site *config.Site,
+	form *config.Form,
+	filter *spam.Engine,
+	payload map[string]any,
+	deps *Deps,
+) bool {
+	ctx, span := tracing.Tracer(tracerName).Start(r.Context(), "score spam")
+	defer span.End()
+
+	result := filter.Evaluate(ctx, &spam.Submission{
+		Payload:   payload,
+		Site:      site.ID,
+		Form:      form.ID,
+		IP:        client.IP(r),
+		UserAgent: r.UserAgent(),
+		Referrer:  r.Referer(),
+	})
+
+	span.SetAttributes(attribute.Float64("bifrost.spam_score", result.Score))
+
+	if !result.Spam {
+		return false
+	}
+
+	deps.Metrics.SpamSubmission(site.ID, form.ID, form.Spam.Action)
+	slog.WarnContext(
+		ctx,
+		"submission scored as spam",
+		"site",
+		site.ID,
+		"form",
+		form.ID,
+		"score",
+		result.Score,
+		"matches",
+		result.Matches,
+		"action",
+		form.Spam.Action,
+	)
+
+	return true
+}
+
+// strike records a strike for the client of the request and logs the ban if
+// the client was banned because of it.
+func strike(r *http.Request, site *config.Site, reason string, deps *Deps) {
+	addr, err := client.Addr(r)
+	if err != nil {
+		return
+	}
+
+	if deps.Bans.Strike(addr, reason) {
+		deps.Metrics.Ban(reason)
+		slog.WarnContext(
+			r.Context(),
+			"banned IP address",
+			"site",
+			site.ID,
+			"remote_ip",
+			deps.Redactor.IP(addr.String()),
+			"reason",
+			reason,
+		)
+	}
+}
 
+// writeFakeSuccess shows the request as a success to not tip off bots.
+func writeFakeSuccess(w http.ResponseWriter, r *http.Request, site *config.Site, form *config.Form) {
+	writeAccepted(w, r, site, form)
+}
+
+// writeAccepted responds to an accepted submission.
+func writeAccepted(w http.ResponseWriter, r *http.Request, site *config.Site, form *config.Form) {
+	lang := responseLang(r, form)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	w.WriteHeader(http.StatusResetContent)
+
+	_, err := w.Write([]byte(i18n.Message(lang, i18n.CodeAccepted, "", form.Messages)))
+	if err != nil {
+		slog.ErrorContext(
+			r.Context(),
+			"failed write response",
+			"path",
+			r.URL.Path,
+			"site",
+			site.ID,
+			"form",
+			form.ID,
+			"err",
+			err.Error(),
+		)
+	}
+}
+
+// writeError responds with the message with the code in the language of
+// the response.
+func writeError(w http.ResponseWriter, r *http.Request, form *config.Form, status int, code string) {
+	lang := responseLang(r, form)
+
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	http.Error(w, i18n.Message(lang, code, "", form.Messages), status)
+}
+
+// responseLang returns the language of the responses to the request. It is
+// selected from the Accept-Language header of the request, and the language
+// of the form is used if none of the accepted languages has messages.
+func responseLang(r *http.Request, form *config.Form) string {
+	return i18n.Negotiate(r.Header.Get("Accept-Language"), form.Lang, form.Messages)
+}
+
+// writePayloadError responds to a request with an invalid payload with the
+// field and the error code so that the client can show the error next to
+// the field.
+func writePayloadError(w http.ResponseWriter, r *http.Request, form *config.Form, err *payloadError) {
+	pointer := err.pointer
+	if pointer == "" {
+		pointer = "/" + escapePointer(err.field)
+	}
+
+	lang := responseLang(r, form)
+	name := err.field
+
+	if field, ok := form.Fields[err.field]; ok {
+		name = field.LocalizedName(err.field, lang)
+	}
+
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	writeJSON(w, r, http.StatusBadRequest, map[string]any{
+		"errors": []map[string]string{
+			{
+				"field":   err.field,
+				"pointer": pointer,
+				"code":    err.reason,
+				"message": i18n.Message(lang, err.reason, name, form.Messages),
+			},
+		},
+	})
+}
+
+// verifyCaptcha verifies the CAPTCHA token of the submission. It writes
+// the error response and returns false if the submission must not be
+// accepted.
+func verifyCaptcha(
+	w http.ResponseWriter,
+	r *http.Request,
+	site *config.Site,
+	form *config.Form,
+	verifier *captcha.Verifier,
+	token any,
+	deps *Deps,
+) bool {
+	ctx, span := tracing.Tracer(tracerName).Start(r.Context(), "verify captcha")
+	defer span.End()
+
+	// A token of the wrong type is treated as missing.
+	s, _ := token.(string)
+
+	err := verifier.Verify(ctx, s, client.IP(r))
+	if err == nil {
+		return true
+	}
+
+	if errors.Is(err, captcha.ErrUnavailable) {
+		slog.ErrorContext(
+			ctx,
+			"failed to verify CAPTCHA",
+			"site",
+			site.ID,
+			"form",
+			form.ID,
+			"provider",
+			form.Captcha.Provider,
+			"err",
+			err.Error(),
+		)
+		writeError(w, r, form, http.StatusServiceUnavailable, i18n.CodeUnavailable)
+
+		return false
+	}
+
+	deps.Metrics.ValidationFailure(site.ID, form.ID, reasonCaptcha)
+	slog.WarnContext(
+		ctx,
+		"reject request with invalid CAPTCHA",
+		"site",
+		site.ID,
+		"form",
+		form.ID,
+		"provider",
+		form.Captcha.Provider,
+		"err",
+		err.Error(),
+	)
+	writeError(w, r, form, http.StatusForbidden, reasonCaptcha)
+
+	return false
+}
+
+func validatePayload(form *config.Form, payload map[string]any) error {
+	for k, v := range payload { //nolint:varnamelen // standard naming
 		cfg, ok := form.Fields[k]
 		if !ok {
-			return &payloadError{field: k, message: fmt.Sprintf("unknown field %q", k)}
+			return &payloadError{field: k, reason: reasonUnknownField, message: fmt.Sprintf("unknown field %q", k)}
 		}
 
 		if k == form.HoneypotField {
@@ -372,6 +767,7 @@ func validatePayload(form *config.Form, payload map[string]any) error {
 			if !ok {
 				return &payloadError{
 					field:   k,
+					reason:  reasonInvalidType,
 					message: fmt.Sprintf("field %q has invalid type %T, expected %s", k, v, cfg.Type.String()),
 				}
 			}
@@ -380,50 +776,15 @@ func validatePayload(form *config.Form, payload map[string]any) error {
 				return &honeypotError{message: fmt.Sprintf("honeypot field %q was set to %q", k, s)}
 			}
 		}
-
-		switch v.(type) {
-		case bool:
-			if cfg.Type != config.FormFieldBool {
-				return &payloadError{
-					field:   k,
-					message: fmt.Sprintf("field %q has invalid type bool, expected %s", k, cfg.Type.String()),
-				}
-			}
-		case float64:
-			if cfg.Type != config.FormFieldInt {
-				return &payloadError{
-					field:   k,
-					message: fmt.Sprintf("field %q has invalid type number, expected %s", k, cfg.Type.String()),
-				}
-			}
-		case string:
-			if cfg.Type != config.FormFieldString {
-				return &payloadError{
-					field:   k,
-					message: fmt.Sprintf("field %q has invalid type string, expected %s", k, cfg.Type.String()),
-				}
-			}
-		case []any:
-			if cfg.Type != config.FormFieldObjects {
-				return &payloadError{
-					field:   k,
-					message: fmt.Sprintf("field %q has invalid type array, expected %s", k, cfg.Type.String()),
-				}
-			}
-		default:
-			return &payloadError{
-				field:   k,
-				message: fmt.Sprintf("field %q has invalid type %T", k, v),
-			}
-		}
 	}
 
 	for k, field := range form.Fields { //nolint:varnamelen // basic names for loopvars
-		_, ok := seenKeys[k]
+		val, ok := payload[k]
 		if !ok {
 			if field.Required {
 				return &payloadError{
 					field:   k,
+					reason:  reasonRequired,
 					message: fmt.Sprintf("missing required field %q", k),
 				}
 			}
@@ -431,429 +792,428 @@ func validatePayload(form *config.Form, payload map[string]any) error {
 			continue
 		}
 
-		val := payload[k]
-
-		switch field.Type {
-		case config.FormFieldBool:
-			b, ok := val.(bool)
-			if !ok {
-				panic(fmt.Sprintf("field %q should have been a bool but it is %T", k, val))
+		normalized, err := validateValue(k, "/"+escapePointer(k), &field, val)
+		if err != nil {
+			var payloadErr *payloadError
+			if errors.As(err, &payloadErr) {
+				payloadErr.field = k
 			}
 
-			if field.Required && !b {
-				return &payloadError{
-					field:   k,
-					message: fmt.Sprintf("field %q is required but its value is false", k),
-				}
-			}
-		case config.FormFieldInt:
-			f, ok := val.(float64)
-			if !ok {
-				panic(fmt.Sprintf("field %q should have been a number but it is %T", k, val))
-			}
+			return err
+		}
 
-			i := int(f)
+		payload[k] = normalized
+	}
 
-			if i < field.Min || i > field.Max {
-				return &payloadError{
-					field: k,
-					message: fmt.Sprintf(
-						"field %q must be between %d and %d but it is %d",
-						k,
-						field.Min,
-						field.Max,
-						i,
-					),
-				}
-			}
+	return validateRules(form, payload)
+}
 
-			payload[k] = i
-		case config.FormFieldString:
-			s, ok := val.(string)
-			if !ok {
-				panic(fmt.Sprintf("field %q should have been a string but it is %T", k, val))
+// validateValue validates the value of a field and returns the normalized
+// value. The name is used in the error messages, and the pointer is the JSON
+// pointer of the value in the payload.
+//
+//nolint:cyclop,funlen,gocognit,gocyclo,maintidx // let's keep this as one function
+func validateValue(name, pointer string, field *config.FormField, val any) (any, error) {
+	switch val.(type) {
+	case bool:
+		if field.Type != config.FormFieldBool {
+			return nil, &payloadError{
+				field:   name,
+				reason:  reasonInvalidType,
+				message: fmt.Sprintf("field %q has invalid type bool, expected %s", name, field.Type.String()),
 			}
-
-			if field.Required && s == "" {
-				return &payloadError{
-					field:   k,
-					message: fmt.Sprintf("field %q is required but its value is empty", k),
-				}
+		}
+	case float64:
+		if !acceptsNumber(field.Type) {
+			return nil, &payloadError{
+				field:   name,
+				reason:  reasonInvalidType,
+				message: fmt.Sprintf("field %q has invalid type number, expected %s", name, field.Type.String()),
 			}
-
-			if (len(s) < field.Min || len(s) > field.Max) && field.Max != 0 {
-				return &payloadError{
-					field: k,
-					message: fmt.Sprintf(
-						"field %q must be between %d and %d characters but it is %d characters",
-						k,
-						field.Min,
-						field.Max,
-						len(s),
-					),
-				}
+		}
+	case string:
+		if !acceptsString(field.Type) {
+			return nil, &payloadError{
+				field:   name,
+				reason:  reasonInvalidType,
+				message: fmt.Sprintf("field %q has invalid type string, expected %s", name, field.Type.String()),
 			}
-		case config.FormFieldObjects:
-			arr, ok := val.([]any)
-			if !ok && field.Required {
-				panic(fmt.Sprintf("field %q should have been an array but it is %T", k, val))
+		}
+	case []any:
+		if field.Type != config.FormFieldObjects && field.Type != config.FormFieldEnumList {
+			return nil, &payloadError{
+				field:   name,
+				reason:  reasonInvalidType,
+				message: fmt.Sprintf("field %q has invalid type array, expected %s", name, field.Type.String()),
 			}
+		}
+	default:
+		return nil, &payloadError{
+			field:   name,
+			reason:  reasonInvalidType,
+			message: fmt.Sprintf("field %q has invalid type %T", name, val),
+		}
+	}
 
-			if field.Required && len(arr) == 0 {
-				return &payloadError{
-					field:   k,
-					message: fmt.Sprintf("field %q is required but its value is empty", k),
-				}
-			}
+	switch field.Type {
+	case config.FormFieldBool:
+		b, ok := val.(bool)
+		if !ok {
+			panic(fmt.Sprintf("field %q should have been a bool but it is %T", name, val))
+		}
 
-			for _, a := range arr {
-				obj, ok := a.(map[string]any)
-				if !ok {
-					return &payloadError{
-						field:   k,
-						message: fmt.Sprintf("could not cast element of field %q to a map", k),
-					}
-				}
+		if field.Required && !b {
+			return nil, &payloadError{
+				field:   name,
+				reason:  reasonRequired,
+				message: fmt.Sprintf("field %q is required but its value is false", name),
+			}
+		}
 
-				seenInObj := map[string]struct{}{}
-
-				for name, value := range obj {
-					var shapeType config.FormFieldType
-
-					shapeType, ok = field.Shape[name]
-					if !ok {
-						return &payloadError{field: k, message: fmt.Sprintf("unknown field %q in field %q", name, k)}
-					}
-
-					seenInObj[name] = struct{}{}
-
-					switch shapeType {
-					case config.FormFieldBool:
-						if _, ok = value.(bool); !ok {
-							return &payloadError{
-								field: k,
-								message: fmt.Sprintf(
-									"value %q in field %q should be bool but it is %T",
-									name,
-									k,
-									value,
-								),
-							}
-						}
-					case config.FormFieldInt:
-						if _, ok = value.(float64); !ok {
-							return &payloadError{
-								field: k,
-								message: fmt.Sprintf(
-									"value %q in field %q should be number but it is %T",
-									name,
-									k,
-									value,
-								),
-							}
-						}
-					case config.FormFieldString:
-						if _, ok = value.(string); !ok {
-							return &payloadError{
-								field: k,
-								message: fmt.Sprintf(
-									"value %q in field %q should be string but it is %T",
-									name,
-									k,
-									value,
-								),
-							}
-						}
-					case config.FormFieldObjects:
-						fallthrough //nolint:gocritic // Just throw the error.
-					default:
-						panic(fmt.Sprintf("value %q in field %q has invalid configured type", name, k))
-					}
-				}
+		return b, nil
+	case config.FormFieldInt:
+		f, ok := val.(float64)
+		if !ok {
+			panic(fmt.Sprintf("field %q should have been a number but it is %T", name, val))
+		}
 
-				for name := range field.Shape {
-					if _, ok = seenInObj[name]; !ok {
-						return &payloadError{
-							field: k,
-							message: fmt.Sprintf(
-								"value %q in field %q missing",
-								name,
-								k,
-							),
-						}
-					}
-				}
-			}
-		default:
-			panic(fmt.Sprintf("invalid form field type: %d", field.Type))
+		i, err := validateInt(name, field, f)
+		if err != nil {
+			return nil, err
 		}
-	}
 
-	return nil
-}
+		return i, nil
+	case config.FormFieldString:
+		s, ok := val.(string)
+		if !ok {
+			panic(fmt.Sprintf("field %q should have been a string but it is %T", name, val))
+		}
 
-func createSMTPTemplates(form *config.Form) ([]sesTemplate, error) {
-	result := make([]sesTemplate, len(form.SESNotifiers))
+		s = sanitizeText(field, s)
 
-	for i, notifier := range form.SESNotifiers {
-		subjTmpl, err := texttemplate.New("subject").Parse(notifier.Subject)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse subject template: %w", err)
+		if field.Required && s == "" {
+			return nil, &payloadError{
+				field:   name,
+				reason:  reasonRequired,
+				message: fmt.Sprintf("field %q is required but its value is empty", name),
+			}
 		}
 
-		var introTmpl *texttemplate.Template
+		if re := field.Regexp(); re != nil && s != "" && !re.MatchString(s) {
+			return nil, &payloadError{
+				field:   name,
+				reason:  reasonInvalidPattern,
+				message: fmt.Sprintf("field %q does not match the pattern %q", name, re.String()),
+			}
+		}
 
-		introTmpl, err = texttemplate.New("intro").Parse(notifier.Intro)
+		err := validateLength(name, field, s)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse intro template: %w", err)
+			return nil, err
 		}
 
-		objs := map[string]*texttemplate.Template{}
-
-		for name, field := range form.Fields {
-			if field.Type != config.FormFieldObjects {
-				continue
-			}
+		return s, nil
+	case config.FormFieldURL,
+		config.FormFieldPhone,
+		config.FormFieldDate,
+		config.FormFieldDateTime:
+		s, ok := val.(string)
+		if !ok {
+			panic(fmt.Sprintf("field %q should have been a string but it is %T", name, val))
+		}
 
-			var obj *texttemplate.Template
+		s = sanitizeText(field, s)
 
-			obj, err = texttemplate.New(name).Parse(field.DisplayTemplate)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse text template for field %q: %w", name, err)
+		if s == "" {
+			if field.Required {
+				return nil, &payloadError{
+					field:   name,
+					reason:  reasonRequired,
+					message: fmt.Sprintf("field %q is required but its value is empty", name),
+				}
 			}
 
-			objs[name] = obj
+			return s, nil
 		}
 
-		var html *template.Template
+		err := validateLength(name, field, s)
+		if err != nil {
+			return nil, err
+		}
 
-		html, err = template.New("html").Funcs(template.FuncMap{
-			"IsObj": func(name string) bool {
-				_, ok := objs[name]
+		return validateFormatted(name, field, s)
+	case config.FormFieldNumber:
+		f, ok := val.(float64)
+		if !ok {
+			panic(fmt.Sprintf("field %q should have been a number but it is %T", name, val))
+		}
 
-				return ok
-			},
-		}).Parse(htmlTemplate)
+		err := validateNumber(name, field, f)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse HTML template: %w", err)
+			return nil, err
 		}
 
-		var (
-			flatTemplate strings.Builder
-			text         *texttemplate.Template
-		)
+		return f, nil
+	case config.FormFieldDecimal:
+		var s string
 
-		for line := range strings.Lines(textTemplate) {
-			flatTemplate.WriteString(strings.TrimLeft(line, " \t"))
+		switch v := val.(type) {
+		case string:
+			s = sanitizeText(field, v)
+		case float64:
+			s = formatNumber(v)
+		default:
+			panic(fmt.Sprintf("field %q should have been a string or a number but it is %T", name, val))
 		}
 
-		text, err = texttemplate.New("text").Funcs(texttemplate.FuncMap{
-			"IsObj": func(name string) bool {
-				_, ok := objs[name]
+		if s == "" {
+			if field.Required {
+				return nil, &payloadError{
+					field:   name,
+					reason:  reasonRequired,
+					message: fmt.Sprintf("field %q is required but its value is empty", name),
+				}
+			}
 
-				return ok
-			},
-		}).Parse(flatTemplate.String())
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse text template: %w", err)
+			return s, nil
 		}
 
-		result[i] = sesTemplate{
-			subject: subjTmpl,
-			intro:   introTmpl,
-			html:    html,
-			text:    text,
-			cfg:     notifier,
-			objs:    objs,
+		return validateDecimal(name, field, s)
+	case config.FormFieldEnum:
+		s, ok := val.(string)
+		if !ok {
+			panic(fmt.Sprintf("field %q should have been a string but it is %T", name, val))
 		}
-	}
 
-	return result, nil
-}
-
-func handleSESNotifiers(
-	w http.ResponseWriter,
-	r *http.Request,
-	form *config.Form,
-	tmpls []sesTemplate,
-	payload map[string]any,
-) error {
-	for _, tmpl := range tmpls {
-		data := map[string]any{}
-		data["payload"] = payload
-		data["fields"] = form.Fields
-		data["lang"] = tmpl.cfg.Lang
-		data["order"] = tmpl.cfg.FieldOrder
-		data["hidden"] = tmpl.cfg.HiddenFields
-
-		var subjBuf bytes.Buffer
-
-		err := tmpl.subject.Execute(&subjBuf, data)
+		err := validateEnum(name, field, []any{s})
 		if err != nil {
-			slog.ErrorContext(
-				r.Context(),
-				"failed to execute subject template",
-				"path",
-				r.URL.Path,
-				"tmpl",
-				tmpl.cfg.Subject,
-				"err",
-				err,
-			)
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return nil, err
+		}
 
-			return fmt.Errorf("failed to execute subject template: %w", err)
+		return s, nil
+	case config.FormFieldEnumList:
+		arr, ok := val.([]any)
+		if !ok {
+			panic(fmt.Sprintf("field %q should have been an array but it is %T", name, val))
 		}
 
-		data["subject"] = subjBuf.String()
+		err := validateEnum(name, field, arr)
+		if err != nil {
+			return nil, err
+		}
 
-		var introBuf bytes.Buffer
+		return arr, nil
+	case config.FormFieldObjects:
+		arr, ok := val.([]any)
+		if !ok {
+			panic(fmt.Sprintf("field %q should have been an array but it is %T", name, val))
+		}
 
-		err = tmpl.intro.Execute(&introBuf, data)
+		err := validateObjects(name, pointer, field, arr)
 		if err != nil {
-			slog.ErrorContext(
-				r.Context(),
-				"failed to execute intro template",
-				"path",
-				r.URL.Path,
-				"tmpl",
-				tmpl.cfg.Intro,
-				"err",
-				err,
-			)
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return nil, err
+		}
 
-			return fmt.Errorf("failed to execute intro template: %w", err)
+		return arr, nil
+	default:
+		panic(fmt.Sprintf("invalid form field type: %d", field.Type))
+	}
+}
+
+// validateObjects validates the objects of an objects field against the shape
+// of the field and normalizes their values in place. The errors in the values
+// of the objects have the JSON pointers of the values.
+func validateObjects(name, pointer string, field *config.FormField, arr []any) error {
+	if field.Required && len(arr) == 0 {
+		return &payloadError{
+			field:   name,
+			reason:  reasonRequired,
+			message: fmt.Sprintf("field %q is required but its value is empty", name),
 		}
+	}
 
-		data["intro"] = introBuf.String()
+	if !inCountBounds(field, len(arr)) {
+		return &payloadError{
+			field:   name,
+			reason:  reasonOutOfRange,
+			message: fmt.Sprintf("field %q must have %s objects but it has %d", name, describeBounds(field), len(arr)),
+		}
+	}
 
-		objs := map[string][]string{}
+	for i, a := range arr {
+		itemPointer := pointer + "/" + strconv.Itoa(i)
 
-		for name, obj := range tmpl.objs {
-			objs[name] = make([]string, 0)
+		obj, ok := a.(map[string]any)
+		if !ok {
+			return &payloadError{
+				field:   name,
+				pointer: itemPointer,
+				reason:  reasonInvalidObject,
+				message: fmt.Sprintf("element %q of field %q is not an object", itemPointer, name),
+			}
+		}
+
+		for key, value := range obj {
+			valuePointer := itemPointer + "/" + escapePointer(key)
 
-			val, ok := payload[name].([]any)
-			if !ok && form.Fields[name].Required {
-				panic(fmt.Sprintf("field %q has a value that is not an array but %T", name, payload[name]))
+			sub, ok := field.Shape[key]
+			if !ok {
+				return &payloadError{
+					field:   name,
+					pointer: valuePointer,
+					reason:  reasonInvalidObject,
+					message: fmt.Sprintf("unknown value %q in field %q", valuePointer, name),
+				}
 			}
 
-			for _, v := range val {
-				var buf bytes.Buffer
-
-				err = obj.Execute(&buf, v)
-				if err != nil {
-					slog.ErrorContext(
-						r.Context(),
-						"failed to execute object template",
-						"path",
-						r.URL.Path,
-						"field",
-						name,
-						"err",
-						err,
-					)
-					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-
-					return fmt.Errorf("failed to execute template for field %q: %w", name, err)
+			normalized, err := validateValue(valuePointer, valuePointer, &sub, value)
+			if err != nil {
+				var payloadErr *payloadError
+				if errors.As(err, &payloadErr) && payloadErr.pointer == "" {
+					payloadErr.pointer = valuePointer
 				}
 
-				objs[name] = append(objs[name], buf.String())
+				return err
 			}
+
+			obj[key] = normalized
 		}
 
-		data["objs"] = objs
+		for key, sub := range field.Shape {
+			if _, ok = obj[key]; !ok && sub.Required {
+				valuePointer := itemPointer + "/" + escapePointer(key)
 
-		var htmlBuf bytes.Buffer
+				return &payloadError{
+					field:   name,
+					pointer: valuePointer,
+					reason:  reasonRequired,
+					message: fmt.Sprintf("missing required value %q in field %q", valuePointer, name),
+				}
+			}
+		}
+	}
 
-		err = tmpl.html.Execute(&htmlBuf, data)
-		if err != nil {
-			slog.ErrorContext(
-				r.Context(),
-				"failed to execute HTML template",
-				"path",
-				r.URL.Path,
-				"err",
-				err,
-			)
+	return nil
+}
 
-			return fmt.Errorf("failed to execute HTML template: %w", err)
+// escapePointer escapes a key for a JSON pointer as specified in RFC 6901.
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// validateEnum checks that the selected values of an enum field are options
+// of the field. An empty string is no selection for [config.FormFieldEnum].
+func validateEnum(name string, field *config.FormField, values []any) error {
+	selected := map[string]struct{}{}
+
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return &payloadError{
+				field:   name,
+				reason:  reasonInvalidType,
+				message: fmt.Sprintf("field %q has a value of invalid type %T, expected string", name, v),
+			}
 		}
 
-		var textBuf bytes.Buffer
+		if s == "" && field.Type == config.FormFieldEnum {
+			continue
+		}
 
-		err = tmpl.text.Execute(&textBuf, data)
-		if err != nil {
-			slog.ErrorContext(
-				r.Context(),
-				"failed to execute text template",
-				"path",
-				r.URL.Path,
-				"err",
-				err,
-			)
+		if _, ok = field.Option(s); !ok {
+			return &payloadError{
+				field:   name,
+				reason:  reasonInvalidOption,
+				message: fmt.Sprintf("field %q has invalid option %q", name, s),
+			}
+		}
 
-			return fmt.Errorf("failed to execute text template: %w", err)
+		if _, ok = selected[s]; ok {
+			return &payloadError{
+				field:   name,
+				reason:  reasonInvalidOption,
+				message: fmt.Sprintf("field %q has option %q selected more than once", name, s),
+			}
 		}
 
-		err = sendSES(r.Context(), tmpl.cfg, subjBuf.String(), htmlBuf.String(), textBuf.String())
-		if err != nil {
-			slog.ErrorContext(
-				r.Context(),
-				"failed to send email",
-				"path",
-				r.URL.Path,
-				"err",
-				err,
-			)
+		selected[s] = struct{}{}
+	}
+
+	if field.Required && len(selected) == 0 {
+		return &payloadError{
+			field:   name,
+			reason:  reasonRequired,
+			message: fmt.Sprintf("field %q is required but nothing is selected", name),
+		}
+	}
 
-			return fmt.Errorf("failed to send email: %w", err)
+	if field.Type == config.FormFieldEnumList && !inCountBounds(field, len(selected)) {
+		return &payloadError{
+			field:  name,
+			reason: reasonOutOfRange,
+			message: fmt.Sprintf(
+				"field %q must have %s options selected but it has %d",
+				name,
+				describeBounds(field),
+				len(selected),
+			),
 		}
 	}
 
 	return nil
 }
 
-func sendSES(
-	ctx context.Context,
-	notifier *config.SESNotifier,
-	subject string,
-	htmlBody string,
-	textBody string,
-) error {
-	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(notifier.Region))
-	if err != nil {
-		return fmt.Errorf("failed to create AWS config: %w", err)
+// saveSubmission saves the accepted submission to the storage if the form is
+// stored. A failure to save is only logged so that the notifiers still run.
+func saveSubmission(
+	r *http.Request,
+	site *config.Site,
+	form *config.Form,
+	payload map[string]any,
+	verdict string,
+	deps *Deps,
+) *storage.Submission {
+	if !form.Store || deps.Store == nil {
+		return nil
 	}
 
-	client := ses.NewFromConfig(cfg)
-	input := &ses.SendEmailInput{ //nolint:exhaustruct // use defaults
-		Destination: &types.Destination{ //nolint:exhaustruct // use defaults
-			ToAddresses: []string{notifier.To},
-		},
-		Message: &types.Message{
-			Body: &types.Body{
-				Html: &types.Content{
-					Charset: aws.String("UTF-8"),
-					Data:    aws.String(htmlBody),
-				},
-				Text: &types.Content{
-					Charset: aws.String("UTF-8"),
-					Data:    aws.String(textBody),
-				},
-			},
-			Subject: &types.Content{
-				Charset: aws.String("UTF-8"),
-				Data:    aws.String(subject),
-			},
-		},
-		Source: aws.String(notifier.From),
+	reqID, _ := logging.RequestID(r.Context())
+	sub := &storage.Submission{
+		ReceivedAt:     time.Now().UTC(),
+		Payload:        payload,
+		Site:           site.ID,
+		Form:           form.ID,
+		RequestID:      reqID,
+		DeliveryStatus: storage.DeliveryPending,
+		DeliveryError:  "",
+		SpamVerdict:    verdict,
+		ID:             0,
 	}
 
-	_, err = client.SendEmail(ctx, input)
+	err := deps.Store.Save(r.Context(), sub)
 	if err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+		slog.ErrorContext(r.Context(), "failed to save submission", "site", site.ID, "form", form.ID, "err", err)
+
+		return nil
 	}
 
-	return nil
+	return sub
+}
+
+// updateDelivery records the result of running the notifiers for a saved
+// submission.
+func updateDelivery(r *http.Request, sub *storage.Submission, sendErr error, deps *Deps) {
+	if sub == nil {
+		return
+	}
+
+	status, message := storage.DeliverySent, ""
+	if sendErr != nil {
+		status, message = storage.DeliveryFailed, sendErr.Error()
+	}
+
+	err := deps.Store.UpdateDelivery(r.Context(), sub.ID, status, message)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to update submission delivery status", "id", sub.ID, "err", err)
+	}
 }
@@ -14,3 +14,25 @@
 
 // Package handlers defines the handlers for the server endpoints.
 package handlers
+
+import (
+	"github.com/visiosto/bifrost/internal/ipfilter"
+	"github.com/visiosto/bifrost/internal/metrics"
+	"github.com/visiosto/bifrost/internal/redact"
+	"github.com/visiosto/bifrost/internal/storage"
+)
+
+// Deps contains the shared services that the handlers depend on.
+type Deps struct {
+	Metrics  *metrics.Metrics
+	Redactor *redact.Redactor
+	Bans     *ipfilter.Bans
+
+	// Store is the submission storage. It is nil if the storage is not
+	// configured.
+	Store storage.Store
+
+	// RecentRequests returns the number of requests that the client with
+	// the IP address has recently made to the site.
+	RecentRequests func(site, ip string) int
+}
@@ -0,0 +1,136 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/visiosto/bifrost/internal/client"
+	"github.com/visiosto/bifrost/internal/config"
+	"github.com/visiosto/bifrost/internal/i18n"
+	"github.com/visiosto/bifrost/internal/idempotency"
+)
+
+// IdempotencyKeyHeader is the name of the HTTP header field that contains
+// the idempotency key of the request.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// recorder records the response that it passes through.
+type recorder struct {
+	http.ResponseWriter
+
+	body   bytes.Buffer
+	status int
+}
+
+func (w *recorder) WriteHeader(statusCode int) {
+	w.status = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *recorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	w.body.Write(b)
+
+	return w.ResponseWriter.Write(b) //nolint:wrapcheck // the writer is only passed through
+}
+
+// Idempotent wraps the form handler so that the repeated submissions get
+// the original response without the form being handled again. The requests
+// are identified by the client IP address and the "Idempotency-Key" header,
+// or the body if the header is not set. A key that is reused with another
+// body is rejected.
+func Idempotent(h http.Handler, site *config.Site, form *config.Form, d *idempotency.Deduper, deps *Deps) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			deps.Metrics.ValidationFailure(site.ID, form.ID, reasonInvalidJSON)
+			writeError(w, r, form, http.StatusBadRequest, i18n.CodeBadRequest)
+
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// The key is scoped to the client so that the other clients cannot
+		// get the response by guessing the key.
+		hash := sha256.New()
+		hash.Write([]byte(FormKey(site.ID, form.ID) + "\x00" + client.IP(r) + "\x00"))
+
+		if key := r.Header.Get(IdempotencyKeyHeader); key != "" {
+			hash.Write([]byte("key\x00" + key))
+		} else {
+			hash.Write([]byte("body\x00"))
+			hash.Write(body)
+		}
+
+		key := hex.EncodeToString(hash.Sum(nil))
+		bodySum := sha256.Sum256(body)
+
+		resp, replayed, err := d.Do(r.Context(), key, hex.EncodeToString(bodySum[:]), func() *idempotency.Response {
+			rec := &recorder{ResponseWriter: w, body: bytes.Buffer{}, status: 0}
+			h.ServeHTTP(rec, r)
+
+			return &idempotency.Response{
+				ContentType: w.Header().Get("Content-Type"),
+				Body:        rec.body.Bytes(),
+				Status:      rec.status,
+			}
+		})
+
+		switch {
+		case errors.Is(err, idempotency.ErrMismatch):
+			slog.WarnContext(r.Context(), "reject reused idempotency key", "site", site.ID, "form", form.ID)
+			writeError(w, r, form, http.StatusUnprocessableEntity, i18n.CodeKeyReused)
+
+			return
+		case err != nil && resp == nil && errors.Is(err, r.Context().Err()):
+			return
+		case err != nil && resp == nil:
+			// The submissions are still accepted if the backend fails.
+			slog.ErrorContext(r.Context(), "failed to check repeated submission", "site", site.ID, "form", form.ID, "err", err)
+			h.ServeHTTP(w, r)
+
+			return
+		case err != nil:
+			slog.ErrorContext(r.Context(), "failed to remember response", "site", site.ID, "form", form.ID, "err", err)
+
+			return
+		case !replayed:
+			return
+		}
+
+		deps.Metrics.DuplicateSubmission(site.ID, form.ID)
+		slog.InfoContext(r.Context(), "replay response to repeated submission", "site", site.ID, "form", form.ID)
+
+		w.Header().Set("Content-Type", resp.ContentType)
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(resp.Status)
+
+		_, err = w.Write(resp.Body)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed write response", "site", site.ID, "form", form.ID, "err", err)
+		}
+	})
+}
@@ -0,0 +1,524 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ses"
+	"github.com/aws/aws-sdk-go-v2/service/ses/types"
+	"github.com/visiosto/bifrost/internal/config"
+	"github.com/visiosto/bifrost/internal/metrics"
+	"github.com/visiosto/bifrost/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//
+//nolint:lll
+const htmlTemplate = `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html lang="{{.lang}}">
+<head>
+<meta charset="utf-8" />
+<meta http-equiv="Content-Type" content="text/html; charset=UTF-8" />
+<title>{{- .subject -}}</title>
+</head>
+<body>
+	<h1>{{.subject}}</h1>
+	{{if (ne .intro "") -}}
+		<p style="font-size: 14px; line-height: 24px; margin: 16px 0">
+			{{- .intro -}}
+		</p>
+	{{end -}}
+	{{$fields := .fields -}}
+	{{$objs := .objs -}}
+	{{$payload := .payload -}}
+	{{$hidden := .hidden -}}
+	{{if (gt (len .order) 0) -}}
+		{{range $key := .order -}}
+			{{$field := index $fields $key -}}
+			{{if (IsObj $key)}}
+				{{$lines := index $objs $key -}}
+				{{if (gt (len $lines) 0) -}}
+					<h2>{{if (eq $field.DisplayName "")}}{{$key}}{{else}}{{$field.DisplayName}}{{end}}</h2>
+					<ul style="font-size: 14px; line-height: 24px; margin: 16px 0">
+						{{range $line := $lines}}
+							<li>{{- $line -}}</li>
+						{{end}}
+					</ul>
+				{{end -}}
+			{{ else -}}
+				<h2>{{if (eq $field.DisplayName "")}}{{$key}}{{else}}{{$field.DisplayName}}{{end}}</h2>
+				<p style="font-size: 14px; line-height: 24px; margin: 16px 0">
+					{{- index $payload $key -}}
+				</p>
+			{{end -}}
+		{{end -}}
+	{{else -}}
+		{{range $key, $value := $payload -}}
+			{{$hide := false}}
+			{{range $k := $hidden}}{{if (eq $k $key)}}{{$hide = true}}{{end}}{{end}}
+			{{if $hide}}{{continue}}{{end}}
+			{{$field := index $fields $key -}}
+			<h2>{{if (eq $field.DisplayName "")}}{{$key}}{{else}}{{$field.DisplayName}}{{end}}</h2>
+			{{if (IsObj $key)}}
+				{{$lines := index $objs $key -}}
+				<ul style="font-size: 14px; line-height: 24px; margin: 16px 0">
+					{{range $line := $lines}}
+						<li>{{- $line -}}</li>
+					{{end}}
+				</ul>
+			{{ else -}}
+				<p style="font-size: 14px; line-height: 24px; margin: 16px 0">
+					{{- $value -}}
+				</p>
+			{{end -}}
+		{{end -}}
+	{{end -}}
+</body>
+</html>
+`
+
+const textTemplate = `{{- if (ne .intro "") -}}{{.intro}}{{- end}}
+{{$fields := .fields -}}
+{{$objs := .objs -}}
+{{$payload := .payload -}}
+{{$hidden := .hidden -}}
+{{if (gt (len .order) 0) -}}
+	{{range $key := .order -}}
+		{{$field := index $fields $key -}}
+		{{if (IsObj $key)}}
+			{{$lines := index $objs $key -}}
+			{{if (gt (len $lines) 0) -}}
+				{{if (eq $field.DisplayName "") -}}{{$key}}{{else -}}{{$field.DisplayName}}{{end}}:
+				{{range $line := $lines}}
+				  - {{$line -}}
+				{{end}}
+			{{end -}}
+		{{ else -}}
+			{{if (eq $field.DisplayName "") -}}{{$key}}{{else -}}{{$field.DisplayName}}{{end}}: {{index $payload $key}}
+		{{end -}}
+	{{end -}}
+{{else -}}
+	{{range $key, $value := $payload -}}
+		{{$hide := false -}}
+		{{range $k := $hidden -}}{{if (eq $k $key) -}}{{$hide = true -}}{{end -}}{{end -}}
+		{{if $hide -}}{{continue -}}{{end -}}
+		{{$field := index $fields $key -}}
+		{{if (IsObj $key)}}
+			{{$lines := index $objs $key -}}
+			{{if (eq $field.DisplayName "") -}}{{$key}}{{else -}}{{$field.DisplayName}}{{end}}:
+			{{range $line := $lines}}
+			  - {{$line -}}
+			{{end}}
+		{{ else -}}
+			{{if (eq $field.DisplayName "") -}}{{$key}}{{else -}}{{$field.DisplayName}}{{end}}: {{$value}}
+		{{end -}}
+	{{end -}}
+{{end}}
+`
+
+var errSESUnavailable = errors.New("SES unavailable")
+
+// Notifiers sends the notifications configured for a form. The same notifiers
+// are used for the new submissions and for resending the stored ones.
+type Notifiers struct {
+	form    *config.Form
+	metrics *metrics.Metrics
+	ses     []sesTemplate
+
+	// quarantine are the notifications that are sent for the spam
+	// submissions if the spam filter of the form quarantines them.
+	quarantine []sesTemplate
+}
+
+type sesMessage struct {
+	subject string
+	html    string
+	text    string
+}
+
+type sesTemplate struct {
+	subject *texttemplate.Template
+	intro   *texttemplate.Template
+	html    *template.Template
+	text    *texttemplate.Template
+	cfg     *config.SESNotifier
+	objs    map[string]*texttemplate.Template
+}
+
+// NewNotifiers parses the notification templates of the form and returns
+// the notifiers for it.
+func NewNotifiers(form *config.Form, m *metrics.Metrics) (*Notifiers, error) {
+	sesTmpls, err := createSMTPTemplates(form, form.SESNotifiers)
+	if err != nil {
+		return nil, err
+	}
+
+	var quarantine []sesTemplate
+
+	if form.Spam != nil {
+		quarantine, err = createSMTPTemplates(form, form.Spam.Quarantine)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Notifiers{form: form, metrics: m, ses: sesTmpls, quarantine: quarantine}, nil
+}
+
+func createSMTPTemplates(form *config.Form, notifiers []*config.SESNotifier) ([]sesTemplate, error) {
+	result := make([]sesTemplate, len(notifiers))
+
+	for i, notifier := range notifiers {
+		subjTmpl, err := texttemplate.New("subject").Parse(notifier.Subject)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse subject template: %w", err)
+		}
+
+		var introTmpl *texttemplate.Template
+
+		introTmpl, err = texttemplate.New("intro").Parse(notifier.Intro)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse intro template: %w", err)
+		}
+
+		objs := map[string]*texttemplate.Template{}
+
+		for name, field := range form.Fields {
+			if field.Type != config.FormFieldObjects {
+				continue
+			}
+
+			var obj *texttemplate.Template
+
+			obj, err = texttemplate.New(name).Parse(field.DisplayTemplate)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse text template for field %q: %w", name, err)
+			}
+
+			objs[name] = obj
+		}
+
+		var html *template.Template
+
+		html, err = template.New("html").Funcs(template.FuncMap{
+			"IsObj": func(name string) bool {
+				_, ok := objs[name]
+
+				return ok
+			},
+		}).Parse(htmlTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse HTML template: %w", err)
+		}
+
+		var (
+			flatTemplate strings.Builder
+			text         *texttemplate.Template
+		)
+
+		for line := range strings.Lines(textTemplate) {
+			flatTemplate.WriteString(strings.TrimLeft(line, " \t"))
+		}
+
+		text, err = texttemplate.New("text").Funcs(texttemplate.FuncMap{
+			"IsObj": func(name string) bool {
+				_, ok := objs[name]
+
+				return ok
+			},
+		}).Parse(flatTemplate.String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse text template: %w", err)
+		}
+
+		result[i] = sesTemplate{
+			subject: subjTmpl,
+			intro:   introTmpl,
+			html:    html,
+			text:    text,
+			cfg:     notifier,
+			objs:    objs,
+		}
+	}
+
+	return result, nil
+}
+
+// Send renders and sends all of the notifications of the form for
+// the payload. It stops at the first notifier that fails.
+func (n *Notifiers) Send(ctx context.Context, payload map[string]any) error {
+	return n.send(ctx, n.ses, payload, "")
+}
+
+// SendSpam sends the notifications for a payload that was scored as spam
+// according to the spam action of the form. The notifications either have
+// the subject tagged or they are sent using the quarantine notifiers.
+func (n *Notifiers) SendSpam(ctx context.Context, payload map[string]any) error {
+	if n.form.Spam != nil && n.form.Spam.Action == config.SpamQuarantine {
+		return n.send(ctx, n.quarantine, payload, "")
+	}
+
+	tag := ""
+	if n.form.Spam != nil {
+		tag = n.form.Spam.SubjectTag
+	}
+
+	return n.send(ctx, n.ses, payload, tag)
+}
+
+func (n *Notifiers) send(ctx context.Context, tmpls []sesTemplate, payload map[string]any, subjectTag string) error {
+	tracer := tracing.Tracer(tracerName)
+
+	for _, tmpl := range tmpls {
+		msg, err := renderSESMessage(ctx, n.form, &tmpl, payload)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to render email", "form", n.form.ID, "err", err)
+
+			return err
+		}
+
+		if subjectTag != "" {
+			msg.subject = subjectTag + " " + msg.subject
+		}
+
+		sendCtx, span := tracer.Start(
+			ctx,
+			"send ses",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attribute.String("aws.region", tmpl.cfg.Region)),
+		)
+
+		start := time.Now()
+		err = sendSES(sendCtx, tmpl.cfg, msg.subject, msg.html, msg.text)
+
+		n.metrics.NotifierSend("ses", time.Since(start), err)
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to send email")
+			span.End()
+			slog.ErrorContext(ctx, "failed to send email", "form", n.form.ID, "err", err)
+
+			return fmt.Errorf("failed to send email: %w", err)
+		}
+
+		span.End()
+	}
+
+	return nil
+}
+
+func renderSESMessage(
+	ctx context.Context,
+	form *config.Form,
+	tmpl *sesTemplate,
+	payload map[string]any,
+) (*sesMessage, error) {
+	_, span := tracing.Tracer(tracerName).Start(ctx, "render ses templates")
+	defer span.End()
+
+	msg, err := executeSESTemplates(form, tmpl, payload)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to render email")
+
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+func executeSESTemplates(form *config.Form, tmpl *sesTemplate, payload map[string]any) (*sesMessage, error) {
+	data := map[string]any{}
+	data["payload"] = displayPayload(form, payload, tmpl.cfg.Lang)
+	data["values"] = payload
+	data["fields"] = form.Fields
+	data["lang"] = tmpl.cfg.Lang
+	data["order"] = tmpl.cfg.FieldOrder
+	data["hidden"] = tmpl.cfg.HiddenFields
+
+	var subjBuf bytes.Buffer
+
+	err := tmpl.subject.Execute(&subjBuf, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute subject template %q: %w", tmpl.cfg.Subject, err)
+	}
+
+	data["subject"] = subjBuf.String()
+
+	var introBuf bytes.Buffer
+
+	err = tmpl.intro.Execute(&introBuf, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute intro template %q: %w", tmpl.cfg.Intro, err)
+	}
+
+	data["intro"] = introBuf.String()
+
+	objs := map[string][]string{}
+
+	for name, obj := range tmpl.objs {
+		objs[name] = make([]string, 0)
+
+		val, ok := payload[name].([]any)
+		if !ok && form.Fields[name].Required {
+			panic(fmt.Sprintf("field %q has a value that is not an array but %T", name, payload[name]))
+		}
+
+		for _, v := range val {
+			var buf bytes.Buffer
+
+			err = obj.Execute(&buf, v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to execute template for field %q: %w", name, err)
+			}
+
+			objs[name] = append(objs[name], buf.String())
+		}
+	}
+
+	data["objs"] = objs
+
+	var htmlBuf bytes.Buffer
+
+	err = tmpl.html.Execute(&htmlBuf, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute HTML template: %w", err)
+	}
+
+	var textBuf bytes.Buffer
+
+	err = tmpl.text.Execute(&textBuf, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute text template: %w", err)
+	}
+
+	return &sesMessage{subject: subjBuf.String(), html: htmlBuf.String(), text: textBuf.String()}, nil
+}
+
+// displayPayload returns a copy of the payload where the values of the enum
+// fields are replaced with their display labels in the given language.
+// The labels of the selected options of [config.FormFieldEnumList] are
+// joined with commas.
+func displayPayload(form *config.Form, payload map[string]any, lang string) map[string]any {
+	result := make(map[string]any, len(payload))
+
+	for name, value := range payload {
+		field := form.Fields[name]
+
+		switch field.Type { //nolint:exhaustive // only the enums have labels
+		case config.FormFieldEnum:
+			s, _ := value.(string)
+			if option, ok := field.Option(s); ok {
+				value = option.Label(lang)
+			}
+		case config.FormFieldEnumList:
+			values, _ := value.([]any)
+			labels := make([]string, 0, len(values))
+
+			for _, v := range values {
+				s, _ := v.(string)
+				if option, ok := field.Option(s); ok {
+					s = option.Label(lang)
+				}
+
+				labels = append(labels, s)
+			}
+
+			value = strings.Join(labels, ", ")
+		}
+
+		result[name] = value
+	}
+
+	return result
+}
+
+func sendSES(
+	ctx context.Context,
+	notifier *config.SESNotifier,
+	subject string,
+	htmlBody string,
+	textBody string,
+) error {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(notifier.Region))
+	if err != nil {
+		return fmt.Errorf("failed to create AWS config: %w", err)
+	}
+
+	client := ses.NewFromConfig(cfg)
+	input := &ses.SendEmailInput{ //nolint:exhaustruct // use defaults
+		Destination: &types.Destination{ //nolint:exhaustruct // use defaults
+			ToAddresses: []string{notifier.To},
+		},
+		Message: &types.Message{
+			Body: &types.Body{
+				Html: &types.Content{
+					Charset: aws.String("UTF-8"),
+					Data:    aws.String(htmlBody),
+				},
+				Text: &types.Content{
+					Charset: aws.String("UTF-8"),
+					Data:    aws.String(textBody),
+				},
+			},
+			Subject: &types.Content{
+				Charset: aws.String("UTF-8"),
+				Data:    aws.String(subject),
+			},
+		},
+		Source: aws.String(notifier.From),
+	}
+
+	_, err = client.SendEmail(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// CheckSES checks that the AWS credentials are valid and that sending email
+// with SES is enabled in the given region.
+func CheckSES(ctx context.Context, region string) error {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return fmt.Errorf("failed to create AWS config: %w", err)
+	}
+
+	out, err := ses.NewFromConfig(cfg).GetAccountSendingEnabled(ctx, &ses.GetAccountSendingEnabledInput{})
+	if err != nil {
+		return fmt.Errorf("failed to get SES account status: %w", err)
+	}
+
+	if !out.Enabled {
+		return fmt.Errorf("%w: sending is disabled in region %s", errSESUnavailable, region)
+	}
+
+	return nil
+}
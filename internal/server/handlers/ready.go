@@ -0,0 +1,62 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/visiosto/bifrost/internal/readiness"
+)
+
+// Ready is the handler for the readiness check route of Bifröst. It responds
+// with the per-check statuses and 503 Service Unavailable if any of
+// the critical checks fails. The errors of the failed checks are only logged
+// as the route is public.
+func Ready(checker *readiness.Checker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := checker.Run(r.Context())
+
+		status := http.StatusOK
+		if report.Status != readiness.StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+
+		for _, result := range report.Checks {
+			if result.Status != readiness.StatusOK {
+				slog.WarnContext(
+					r.Context(),
+					"readiness check failed",
+					"check",
+					result.Name,
+					"critical",
+					result.Critical,
+					"err",
+					result.Error,
+				)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+
+		err := json.NewEncoder(w).Encode(report)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to write readiness report", "err", err)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/visiosto/bifrost/internal/config"
+)
+
+// Reasons for rejecting the payloads that break the rules of the form.
+const (
+	reasonMismatch          = "mismatch"
+	reasonOneOf             = "one_of"
+	reasonMutuallyExclusive = "mutually_exclusive"
+)
+
+// validateRules checks the payload against the cross-field rules of the form.
+// The fields must have been validated before so that the values are
+// normalized.
+func validateRules(form *config.Form, payload map[string]any) error {
+	rules := &form.Rules
+
+	for _, rule := range rules.RequiredIf {
+		if !isSet(payload[rule.If]) || isSet(payload[rule.Field]) {
+			continue
+		}
+
+		if rule.Equals != nil && !matchesValue(payload[rule.If], rule.Equals) {
+			continue
+		}
+
+		message := fmt.Sprintf("field %q is required when field %q is set", rule.Field, rule.If)
+		if rule.Equals != nil {
+			message = fmt.Sprintf("field %q is required when field %q is %v", rule.Field, rule.If, rule.Equals)
+		}
+
+		return &payloadError{field: rule.Field, reason: reasonRequired, message: message}
+	}
+
+	for _, rule := range rules.EqualsField {
+		a, b := payload[rule.Field], payload[rule.Other]
+		if !isSet(a) && !isSet(b) {
+			continue
+		}
+
+		if !reflect.DeepEqual(a, b) {
+			return &payloadError{
+				field:   rule.Field,
+				reason:  reasonMismatch,
+				message: fmt.Sprintf("field %q must have the same value as field %q", rule.Field, rule.Other),
+			}
+		}
+	}
+
+	for _, group := range rules.OneOf {
+		if !slices.ContainsFunc(group, func(name string) bool { return isSet(payload[name]) }) {
+			return &payloadError{
+				field:   group[0],
+				reason:  reasonOneOf,
+				message: fmt.Sprintf("at least one of the fields %s is required", quoteFields(group)),
+			}
+		}
+	}
+
+	for _, group := range rules.MutuallyExclusive {
+		var set []string
+
+		for _, name := range group {
+			if isSet(payload[name]) {
+				set = append(set, name)
+			}
+		}
+
+		if len(set) > 1 {
+			return &payloadError{
+				field:   set[1],
+				reason:  reasonMutuallyExclusive,
+				message: fmt.Sprintf("only one of the fields %s can be set", quoteFields(group)),
+			}
+		}
+	}
+
+	return nil
+}
+
+// isSet reports whether the value of a field counts as set for the rules.
+// The empty strings and lists and false are not set.
+func isSet(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case string:
+		return v != ""
+	case bool:
+		return v
+	case []any:
+		return len(v) > 0
+	default:
+		return true
+	}
+}
+
+// matchesValue reports whether the payload value matches the value in
+// a rule. A list matches if it contains the value.
+func matchesValue(v, want any) bool {
+	if list, ok := v.([]any); ok {
+		return slices.ContainsFunc(list, func(x any) bool { return matchesValue(x, want) })
+	}
+
+	return fmt.Sprint(v) == fmt.Sprint(want)
+}
+
+func quoteFields(names []string) string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, fmt.Sprintf("%q", name))
+	}
+
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,43 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/visiosto/bifrost/internal/sdk"
+)
+
+// sdkMaxAge is the time in seconds that the browsers may cache the SDK
+// before revalidating it.
+const sdkMaxAge = "3600"
+
+// SDK is the handler for the JavaScript SDK. The ETag of the SDK is derived
+// from its source so that the browsers can revalidate their cached copy
+// cheaply.
+func SDK() http.Handler {
+	sum := sha256.Sum256(sdk.Source)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age="+sdkMaxAge)
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "sdk.js", time.Time{}, bytes.NewReader(sdk.Source))
+	})
+}
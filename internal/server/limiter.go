@@ -33,8 +33,9 @@ type fixedWindowLimiter struct {
 }
 
 type bucket struct {
-	resetAt time.Time
-	count   int
+	resetAt  time.Time
+	count    int
+	rejected bool
 }
 
 func newFixedWindowLimiter(ctx context.Context, limit int, window time.Duration) (*fixedWindowLimiter, error) {
@@ -56,7 +57,10 @@ func newFixedWindowLimiter(ctx context.Context, limit int, window time.Duration)
 	}, nil
 }
 
-func (l *fixedWindowLimiter) allow(key string) bool {
+// allow reports whether the request with the key is allowed. The second
+// return value is true only for the first rejected request in the window so
+// that the callers can act on the exceeded limit once per window.
+func (l *fixedWindowLimiter) allow(key string) (bool, bool) {
 	now := time.Now()
 
 	l.mu.Lock()
@@ -65,18 +69,42 @@ func (l *fixedWindowLimiter) allow(key string) bool {
 	b, ok := l.buckets[key]
 	if !ok || now.After(b.resetAt) {
 		l.buckets[key] = &bucket{
-			count:   1,
-			resetAt: now.Add(l.window),
+			count:    1,
+			resetAt:  now.Add(l.window),
+			rejected: false,
 		}
 
-		return true
+		return true, false
 	}
 
 	if b.count >= l.limit {
-		return false
+		first := !b.rejected
+		b.rejected = true
+
+		return false, first
 	}
 
 	b.count++
 
-	return true
+	return true, false
+}
+
+func (l *fixedWindowLimiter) size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.buckets)
+}
+
+// count returns the number of requests with the key in the current window.
+func (l *fixedWindowLimiter) count(key string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok || time.Now().After(b.resetAt) {
+		return 0
+	}
+
+	return b.count
 }
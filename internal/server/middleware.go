@@ -19,20 +19,34 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"log/slog"
-	"net"
 	"net/http"
+	"net/netip"
 	"slices"
 	"strings"
 	"time"
 
+	"github.com/visiosto/bifrost/internal/client"
 	"github.com/visiosto/bifrost/internal/config"
+	"github.com/visiosto/bifrost/internal/i18n"
+	"github.com/visiosto/bifrost/internal/ipfilter"
+	"github.com/visiosto/bifrost/internal/logging"
+	"github.com/visiosto/bifrost/internal/metrics"
+	"github.com/visiosto/bifrost/internal/redact"
+	"github.com/visiosto/bifrost/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
-	ctxKeyRequestID ctxKey = iota
-	ctxKeySite
+	ctxKeySite ctxKey = iota
+	ctxKeyForm
 )
 
+const tracerName = "github.com/visiosto/bifrost/internal/server"
+
 type ctxKey int
 
 type responseWriter struct {
@@ -46,17 +60,31 @@ func (w *responseWriter) WriteHeader(statusCode int) {
 	w.ResponseWriter.WriteHeader(statusCode)
 }
 
-func withMiddleware(h http.Handler, cfg *config.Config, l *fixedWindowLimiter, paths map[string]pathInfo) http.Handler {
-	h = rateLimit(h, l)
-	h = verifyToken(h, paths)
-	h = corsByPath(h, paths)
-	h = pathContext(h, paths)
+func withMiddleware(
+	h http.Handler,
+	cfg *config.Config,
+	l *fixedWindowLimiter,
+	filter *ipfilter.Filter,
+	bans *ipfilter.Bans,
+	trustedProxies []netip.Prefix,
+	paths map[string]pathInfo,
+	m *metrics.Metrics,
+	red *redact.Redactor,
+) http.Handler {
+	h = traced("rateLimit", h, func(h http.Handler) http.Handler { return rateLimit(h, l, bans, m, red) })
+	h = traced("verifyToken", h, func(h http.Handler) http.Handler { return verifyToken(h, paths) })
+	h = traced("corsByPath", h, func(h http.Handler) http.Handler { return corsByPath(h, paths) })
+	h = traced("ipFilter", h, func(h http.Handler) http.Handler { return ipFilter(h, filter, m, red) })
+	h = traced("pathContext", h, func(h http.Handler) http.Handler { return pathContext(h, paths) })
 
 	if cfg.DebugHeaders {
-		h = debugHeaders(h)
+		h = debugHeaders(h, red)
 	}
 
-	h = accessLogger(h)
+	h = accessLogger(h, red)
+	h = instrument(h, paths, m)
+	h = traceRequest(h, paths, red)
+	h = clientIP(h, trustedProxies)
 	h = requestID(h)
 	h = http.MaxBytesHandler(h, cfg.MaxBodyBytes)
 	h = recoverer(h)
@@ -77,6 +105,14 @@ func recoverer(h http.Handler) http.Handler {
 	})
 }
 
+// clientIP resolves the client IP address of the request so that it is only
+// taken from the X-Real-IP header if the request comes from a trusted proxy.
+func clientIP(h http.Handler, trustedProxies []netip.Prefix) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, client.WithIP(r, trustedProxies))
+	})
+}
+
 func requestID(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var b [16]byte
@@ -91,24 +127,90 @@ func requestID(h http.Handler) http.Handler {
 
 		id := hex.EncodeToString(b[:])
 		w.Header().Set("X-Request-Id", id)
-		ctx := context.WithValue(r.Context(), ctxKeyRequestID, id)
-		h.ServeHTTP(w, r.WithContext(ctx))
+		h.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
 	})
 }
 
-func accessLogger(h http.Handler) http.Handler {
+// traceRequest starts the server span for the request. The span continues
+// the trace from the incoming "traceparent" header if there is one.
+func traceRequest(h http.Handler, paths map[string]pathInfo, red *redact.Redactor) http.Handler {
+	tracer := tracing.Tracer(tracerName)
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		attrs := []attribute.KeyValue{
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+			attribute.String("client.address", red.IP(client.IP(r))),
+		}
+
+		// Unknown paths are not used in the span names to keep them bounded.
+		name := r.Method
+		if info, ok := paths[r.URL.Path]; ok {
+			name = r.Method + " " + r.URL.Path
+			attrs = append(attrs, attribute.String("bifrost.site", info.site))
+
+			if info.form != "" {
+				attrs = append(attrs, attribute.String("bifrost.form", info.form))
+			}
+		}
+
+		if reqID, ok := logging.RequestID(ctx); ok {
+			attrs = append(attrs, attribute.String("bifrost.request_id", reqID))
+		}
+
+		ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+		defer span.End()
+
 		rw := &responseWriter{ResponseWriter: w, status: 0}
 
-		h.ServeHTTP(rw, r)
+		h.ServeHTTP(rw, r.WithContext(ctx))
 
-		reqID, ok := r.Context().Value(ctxKeyRequestID).(string)
-		if !ok {
-			slog.ErrorContext(r.Context(), "request_id is not a string")
+		status := rw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
 
-			reqID = "unknown"
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
 		}
+	})
+}
+
+// traced wraps the middleware created by mw around h in an internal span with
+// the given name so that the time spent in each part of the middleware chain
+// is visible in the traces. The span ends when the middleware passes
+// the request on to h so that it covers only the work of the middleware.
+func traced(name string, h http.Handler, mw func(http.Handler) http.Handler) http.Handler {
+	tracer := tracing.Tracer(tracerName)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parent := trace.SpanFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "middleware "+name)
+		defer span.End()
+
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			span.End()
+
+			// The rest of the chain is not part of the work of the middleware
+			// so its spans are children of the parent of the middleware span.
+			h.ServeHTTP(w, r.WithContext(trace.ContextWithSpan(r.Context(), parent)))
+		})
+
+		mw(next).ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func accessLogger(h http.Handler, red *redact.Redactor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w, status: 0}
+
+		h.ServeHTTP(rw, r)
 
 		slog.InfoContext(
 			r.Context(),
@@ -122,22 +224,36 @@ func accessLogger(h http.Handler) http.Handler {
 			"duration_ms",
 			time.Since(start).Milliseconds(),
 			"remote_ip",
-			remoteIP(r),
-			"request_id",
-			reqID,
+			red.IP(client.IP(r)),
 		)
 	})
 }
 
-func debugHeaders(h http.Handler) http.Handler {
+func instrument(h http.Handler, paths map[string]pathInfo, m *metrics.Metrics) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		reqID, ok := r.Context().Value(ctxKeyRequestID).(string)
-		if !ok {
-			slog.ErrorContext(r.Context(), "request_id is not a string")
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w, status: 0}
+
+		h.ServeHTTP(rw, r)
+
+		// Unknown paths are not labeled by the path to keep the cardinality of
+		// the metrics bounded.
+		site, form := "unknown", ""
+		if info, ok := paths[r.URL.Path]; ok {
+			site, form = info.site, info.form
+		}
 
-			reqID = "unknown"
+		status := rw.status
+		if status == 0 {
+			status = http.StatusOK
 		}
 
+		m.ObserveRequest(site, form, status, time.Since(start))
+	})
+}
+
+func debugHeaders(h http.Handler, red *redact.Redactor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		slog.DebugContext(
 			r.Context(),
 			"request headers",
@@ -145,10 +261,8 @@ func debugHeaders(h http.Handler) http.Handler {
 			r.Method,
 			"path",
 			r.URL.Path,
-			"request_id",
-			reqID,
 			"header",
-			r.Header,
+			red.Headers(r.Header),
 		)
 		h.ServeHTTP(w, r)
 	})
@@ -173,6 +287,10 @@ func pathContext(h http.Handler, paths map[string]pathInfo) http.Handler {
 		slog.DebugContext(r.Context(), "assigning site", "site", info.site, "path", r.URL.Path)
 
 		ctx := context.WithValue(r.Context(), ctxKeySite, info.site)
+		if info.formConfig != nil {
+			ctx = context.WithValue(ctx, ctxKeyForm, info.formConfig)
+		}
+
 		h.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -191,7 +309,7 @@ func corsByPath(h http.Handler, paths map[string]pathInfo) http.Handler {
 
 		info, ok := paths[path]
 		if !ok {
-			http.Error(w, "Forbidden", http.StatusForbidden)
+			writeError(w, r, http.StatusForbidden, i18n.CodeForbidden)
 
 			return
 		}
@@ -200,13 +318,13 @@ func corsByPath(h http.Handler, paths map[string]pathInfo) http.Handler {
 
 		origin := r.Header.Get("Origin")
 		if origin == "" && !wildcard {
-			http.Error(w, "Forbidden", http.StatusForbidden)
+			writeError(w, r, http.StatusForbidden, i18n.CodeForbidden)
 
 			return
 		}
 
 		if !wildcard && !slices.Contains(info.allowedOrigins, origin) {
-			http.Error(w, "Forbidden", http.StatusForbidden)
+			writeError(w, r, http.StatusForbidden, i18n.CodeForbidden)
 
 			return
 		}
@@ -238,7 +356,7 @@ func verifyToken(h http.Handler, paths map[string]pathInfo) http.Handler {
 
 		info, ok := paths[path]
 		if !ok {
-			http.Error(w, "Forbidden", http.StatusForbidden)
+			writeError(w, r, http.StatusForbidden, i18n.CodeForbidden)
 
 			return
 		}
@@ -253,15 +371,22 @@ func verifyToken(h http.Handler, paths map[string]pathInfo) http.Handler {
 		if token == "" {
 			slog.WarnContext(r.Context(), "disallow request due to empty token", "path", path)
 			w.Header().Set("WWW-Authenticate", config.SiteTokenHeader)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			writeError(w, r, http.StatusUnauthorized, i18n.CodeUnauthorized)
 
 			return
 		}
 
 		if token != info.token {
-			slog.WarnContext(r.Context(), "disallow request due to invalid token", "token", token, "path", path)
+			slog.WarnContext(
+				r.Context(),
+				"disallow request due to invalid token",
+				"token",
+				redact.Token(token),
+				"path",
+				path,
+			)
 			w.Header().Set("WWW-Authenticate", config.SiteTokenHeader)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			writeError(w, r, http.StatusUnauthorized, i18n.CodeUnauthorized)
 
 			return
 		}
@@ -270,7 +395,56 @@ func verifyToken(h http.Handler, paths map[string]pathInfo) http.Handler {
 	})
 }
 
-func rateLimit(h http.Handler, l *fixedWindowLimiter) http.Handler {
+func limiterKey(site, ip string) string {
+	return site + "|" + ip
+}
+
+// ipFilter rejects the requests from the blocked networks and the banned IP
+// addresses.
+func ipFilter(h http.Handler, filter *ipfilter.Filter, m *metrics.Metrics, red *redact.Redactor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		site, _ := r.Context().Value(ctxKeySite).(string)
+
+		// The requests without a valid client address are rejected so that
+		// they cannot bypass the filter.
+		addr, err := client.Addr(r)
+		if err != nil {
+			slog.WarnContext(r.Context(), "disallow request with invalid client IP address", "site", site, "err", err)
+			m.BlockedRequest(site, "invalid_address")
+			writeError(w, r, http.StatusForbidden, i18n.CodeForbidden)
+
+			return
+		}
+
+		decision := filter.Check(site, addr)
+		if decision != ipfilter.Allow {
+			slog.WarnContext(
+				r.Context(),
+				"disallow request from filtered IP address",
+				"site",
+				site,
+				"remote_ip",
+				red.IP(addr.String()),
+				"reason",
+				decision.String(),
+			)
+			m.BlockedRequest(site, decision.String())
+			writeError(w, r, http.StatusForbidden, i18n.CodeForbidden)
+
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+func rateLimit(
+	h http.Handler,
+	l *fixedWindowLimiter,
+	bans *ipfilter.Bans,
+	m *metrics.Metrics,
+	red *redact.Redactor,
+) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		site, ok := r.Context().Value(ctxKeySite).(string)
 		if !ok {
@@ -280,10 +454,20 @@ func rateLimit(h http.Handler, l *fixedWindowLimiter) http.Handler {
 			return
 		}
 
-		key := site + "|" + remoteIP(r)
-		if !l.allow(key) {
-			slog.WarnContext(r.Context(), "rate limit exceeded", "key", key)
-			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+		ip := client.IP(r)
+
+		if allowed, first := l.allow(limiterKey(site, ip)); !allowed {
+			slog.WarnContext(r.Context(), "rate limit exceeded", "site", site, "remote_ip", red.IP(ip))
+			m.RateLimitRejection(site)
+
+			// Only the first rejection in the window is a strike so that
+			// a client that keeps retrying is not banned for a single burst.
+			if addr, err := netip.ParseAddr(ip); first && err == nil && bans.Strike(addr, "rate_limit") {
+				slog.WarnContext(r.Context(), "banned IP address", "site", site, "remote_ip", red.IP(ip), "reason", "rate_limit")
+				m.Ban("rate_limit")
+			}
+
+			writeError(w, r, http.StatusTooManyRequests, i18n.CodeTooManyRequests)
 
 			return
 		}
@@ -292,16 +476,20 @@ func rateLimit(h http.Handler, l *fixedWindowLimiter) http.Handler {
 	})
 }
 
-func remoteIP(r *http.Request) string {
-	realIP := r.Header.Get("X-Real-IP")
-	if realIP != "" {
-		return realIP
-	}
+// writeError responds with the message of the code in the language of
+// the request on the form paths. The other paths respond with the status
+// text.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code string) {
+	form, ok := r.Context().Value(ctxKeyForm).(*config.Form)
+	if !ok {
+		http.Error(w, http.StatusText(status), status)
 
-	host, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return r.RemoteAddr
+		return
 	}
 
-	return host
+	lang := i18n.Negotiate(r.Header.Get("Accept-Language"), form.Lang, form.Messages)
+
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	http.Error(w, i18n.Message(lang, code, "", form.Messages), status)
 }
@@ -0,0 +1,73 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/visiosto/bifrost/internal/config"
+	"github.com/visiosto/bifrost/internal/storage"
+)
+
+// retentionInterval is the interval of the retention sweeps.
+const retentionInterval = time.Hour
+
+// sweepRetention deletes the stored submissions that are older than
+// the retention period of their form once every [retentionInterval] until ctx
+// is canceled.
+func sweepRetention(ctx context.Context, cfg *config.Config, store storage.Store) {
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+
+	for {
+		deleteExpired(ctx, cfg, store, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func deleteExpired(ctx context.Context, cfg *config.Config, store storage.Store, now time.Time) {
+	for _, site := range cfg.Sites {
+		for _, form := range site.Forms {
+			retention := site.Retention(&form)
+			if !form.Store || retention == 0 {
+				continue
+			}
+
+			filter := &storage.Filter{ //nolint:exhaustruct // match only by form and time
+				Site: site.ID,
+				Form: form.ID,
+				To:   now.Add(-retention),
+			}
+
+			n, err := store.DeleteMatching(ctx, filter)
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to delete expired submissions", "site", site.ID, "form", form.ID, "err", err)
+
+				continue
+			}
+
+			if n > 0 {
+				slog.InfoContext(ctx, "deleted expired submissions", "site", site.ID, "form", form.ID, "count", n)
+			}
+		}
+	}
+}
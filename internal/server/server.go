@@ -17,24 +17,56 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/visiosto/bifrost/internal/config"
+	"github.com/visiosto/bifrost/internal/idempotency"
+	"github.com/visiosto/bifrost/internal/ipfilter"
+	"github.com/visiosto/bifrost/internal/metrics"
+	"github.com/visiosto/bifrost/internal/readiness"
+	"github.com/visiosto/bifrost/internal/redact"
 	"github.com/visiosto/bifrost/internal/server/handlers"
+	"github.com/visiosto/bifrost/internal/storage"
 )
 
 const apiPrefix = "/v1"
 
+var (
+	errNoSites     = errors.New("no sites configured")
+	errIdempotency = errors.New("invalid idempotency backend")
+)
+
 // Server contains the HTTP server and the configured modules.
 type Server struct {
 	HTTPServer *http.Server
+
+	// AdminServer is the HTTP server for the admin endpoints. It is nil if
+	// the admin listener is not configured.
+	AdminServer *http.Server
+
+	// Store is the submission storage. It is nil if the storage is not
+	// configured.
+	Store storage.Store
+
+	// stopBackground stops the background tasks of the server.
+	stopBackground context.CancelFunc
+	background     *sync.WaitGroup
 }
 
 type pathInfo struct {
+	// formConfig is the config of the form for the form paths. It selects
+	// the language and the messages of the error responses of
+	// the middleware.
+	formConfig     *config.Form
 	site           string
+	form           string
 	token          string
 	allowedOrigins []string
 }
@@ -46,13 +78,58 @@ func New(ctx context.Context, cfg *config.Config) (*Server, error) {
 		return nil, err
 	}
 
+	m := metrics.New()
+	m.RegisterLimiterBuckets(func() float64 { return float64(limiter.size()) })
+
+	store, err := storage.Open(ctx, &cfg.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open storage: %w", err)
+	}
+
+	red, err := redact.New(&cfg.Redaction)
+	if err != nil {
+		return nil, err
+	}
+
+	bans := ipfilter.NewBans(&cfg.Bans)
+
+	filter, err := ipfilter.New(cfg, bans)
+	if err != nil {
+		return nil, err
+	}
+
+	deps := &handlers.Deps{
+		Metrics:  m,
+		Redactor: red,
+		Bans:     bans,
+		Store:    store,
+		RecentRequests: func(site, ip string) int {
+			return limiter.count(limiterKey(site, ip))
+		},
+	}
+	notifiers := map[string]*handlers.Notifiers{}
+
+	deduper, err := newDeduper(&cfg.Idempotency, store)
+	if err != nil {
+		return nil, err
+	}
+
 	// Map the allowed origins and sites to the created paths.
 	paths := make(map[string]pathInfo)
 	mux := http.NewServeMux()
 
 	paths["/health"] = pathInfo{site: "_", token: "", allowedOrigins: []string{"*"}}
+	paths["/ready"] = pathInfo{site: "_", token: "", allowedOrigins: []string{"*"}}
+
+	checker := newReadinessChecker(cfg, store)
 
 	mux.Handle("/health", handlers.Health())
+	mux.Handle("/ready", handlers.Ready(checker))
+
+	sdkPath := apiPrefix + "/sdk.js"
+	paths[sdkPath] = pathInfo{site: "_", token: "", allowedOrigins: []string{"*"}}
+
+	mux.Handle("GET "+sdkPath, handlers.SDK())
 
 	for _, site := range cfg.Sites {
 		slog.DebugContext(ctx, "registering handlers for site", "site", site.ID)
@@ -62,19 +139,69 @@ func New(ctx context.Context, cfg *config.Config) (*Server, error) {
 
 			slog.DebugContext(ctx, "registering handler for form", "site", site.ID, "form", form.ID, "path", path)
 
-			paths[path] = pathInfo{site: site.ID, token: site.Token, allowedOrigins: site.AllowedOrigins}
+			paths[path] = pathInfo{
+				formConfig:     &form,
+				site:           site.ID,
+				form:           form.ID,
+				token:          site.Token,
+				allowedOrigins: site.AllowedOrigins,
+			}
 
-			formHandler, err := handlers.SubmitForm(&site, &form)
+			formNotifiers, err := handlers.NewNotifiers(&form, m)
 			if err != nil {
-				return nil, fmt.Errorf("failed to create handler for form %q: %w", path, err)
+				return nil, fmt.Errorf("failed to create notifiers for form %q: %w", path, err)
+			}
+
+			notifiers[handlers.FormKey(site.ID, form.ID)] = formNotifiers
+
+			submit := handlers.SubmitForm(&site, &form, formNotifiers, deps)
+			if deduper != nil {
+				submit = handlers.Idempotent(submit, &site, &form, deduper, deps)
 			}
 
-			mux.Handle("POST "+path, formHandler)
+			mux.Handle("POST "+path, submit)
 			mux.Handle("OPTIONS "+path, handlers.FormPreflight(&form))
+
+			if form.TimeTrap != nil {
+				timestampPath := path + "/timestamp"
+				paths[timestampPath] = paths[path]
+
+				mux.Handle("GET "+timestampPath, handlers.FormTimestamp(&site, &form))
+				mux.Handle("OPTIONS "+timestampPath, handlers.FormGetPreflight(&form))
+			}
+
+			if form.ProofOfWork != nil {
+				challengePath := path + "/challenge"
+				paths[challengePath] = paths[path]
+
+				mux.Handle("GET "+challengePath, handlers.FormChallenge(&site, &form, deps))
+				mux.Handle("OPTIONS "+challengePath, handlers.FormGetPreflight(&form))
+			}
+
+			if form.PublishSchema {
+				schemaPath := path + "/schema"
+				paths[schemaPath] = paths[path]
+
+				mux.Handle("GET "+schemaPath, handlers.FormSchema(&form))
+				mux.Handle("OPTIONS "+schemaPath, handlers.FormGetPreflight(&form))
+			}
 		}
 	}
 
-	handler := withMiddleware(mux, cfg, limiter, paths)
+	trustedProxies, err := config.ParseCIDRs(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(trustedProxies) == 0 {
+		slog.WarnContext(
+			ctx,
+			"no trusted proxies are configured, the X-Real-IP header is trusted from all addresses; "+
+				"set trustedProxies to the networks of the reverse proxies",
+		)
+	}
+
+	handler := withMiddleware(mux, cfg, limiter, filter, bans, trustedProxies, paths, m, red)
 	httpServer := &http.Server{ //nolint:exhaustruct // use defaults
 		Addr:              cfg.ListenAddr,
 		Handler:           handler,
@@ -84,27 +211,148 @@ func New(ctx context.Context, cfg *config.Config) (*Server, error) {
 		ReadHeaderTimeout: 2 * time.Second,  //nolint:mnd
 	}
 
+	bgCtx, stopBackground := context.WithCancel(context.WithoutCancel(ctx))
+
+	background := &sync.WaitGroup{}
+
+	if store != nil {
+		background.Go(func() { sweepRetention(bgCtx, cfg, store) })
+	}
+
 	return &Server{
-		HTTPServer: httpServer,
+		HTTPServer:     httpServer,
+		AdminServer:    newAdminServer(ctx, cfg, m, red, store, notifiers, bans),
+		Store:          store,
+		stopBackground: stopBackground,
+		background:     background,
 	}, nil
 }
 
-// Run runs the server.
+// Run runs the server and the admin server if it is configured. It returns
+// when either of them stops.
 func (s *Server) Run() error {
-	err := s.HTTPServer.ListenAndServe()
-	if err != nil {
-		return fmt.Errorf("unexpected error in server: %w", err)
+	errCh := make(chan error, 2) //nolint:mnd // one for each server
+
+	go func() {
+		err := s.HTTPServer.ListenAndServe()
+		if err != nil {
+			errCh <- fmt.Errorf("unexpected error in server: %w", err)
+
+			return
+		}
+
+		errCh <- nil
+	}()
+
+	if s.AdminServer != nil {
+		go func() {
+			err := s.AdminServer.ListenAndServe()
+			if err != nil {
+				errCh <- fmt.Errorf("unexpected error in admin server: %w", err)
+
+				return
+			}
+
+			errCh <- nil
+		}()
 	}
 
-	return nil
+	return <-errCh
 }
 
-// Shutdown tries to shut down the server gracefully.
+// Shutdown tries to shut down the server and the admin server gracefully.
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.stopBackground()
+	s.background.Wait()
+
 	err := s.HTTPServer.Shutdown(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to shut down the server: %w", err)
 	}
 
+	if s.AdminServer != nil {
+		err = s.AdminServer.Shutdown(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to shut down the admin server: %w", err)
+		}
+	}
+
+	if s.Store != nil {
+		err = s.Store.Close()
+		if err != nil {
+			return fmt.Errorf("failed to close the storage: %w", err)
+		}
+	}
+
 	return nil
 }
+
+// newDeduper returns the deduper for the form submissions or nil if
+// the repeated submissions are not detected.
+func newDeduper(cfg *config.Idempotency, store storage.Store) (*idempotency.Deduper, error) {
+	if cfg.WindowSeconds == 0 {
+		return nil, nil //nolint:nilnil // idempotency not configured
+	}
+
+	window := time.Duration(cfg.WindowSeconds) * time.Second
+
+	if cfg.Backend != config.IdempotencyStorage {
+		return idempotency.New(idempotency.NewMemory(), window), nil
+	}
+
+	backend, ok := store.(idempotency.Backend)
+	if !ok {
+		return nil, fmt.Errorf("%w: the storage cannot be used as the idempotency backend", errIdempotency)
+	}
+
+	return idempotency.New(backend, window), nil
+}
+
+func newReadinessChecker(cfg *config.Config, store storage.Store) *readiness.Checker {
+	checker := readiness.NewChecker(
+		time.Duration(cfg.Readiness.CacheSeconds)*time.Second,
+		time.Duration(cfg.Readiness.TimeoutSeconds)*time.Second,
+	)
+
+	checker.Register(readiness.Check{
+		Name:     "config",
+		Critical: true,
+		Run: func(context.Context) error {
+			if len(cfg.Sites) == 0 {
+				return errNoSites
+			}
+
+			return nil
+		},
+	})
+
+	if store != nil {
+		checker.Register(readiness.Check{
+			Name:     "storage",
+			Critical: true,
+			Run:      store.Ping,
+		})
+	}
+
+	regions := map[string]struct{}{}
+
+	for _, site := range cfg.Sites {
+		for _, form := range site.Forms {
+			for _, notifier := range form.SESNotifiers {
+				regions[notifier.Region] = struct{}{}
+			}
+		}
+	}
+
+	for _, region := range slices.Sorted(maps.Keys(regions)) {
+		checker.Register(readiness.Check{
+			Name:     "ses:" + region,
+			Critical: true,
+			Run: func(ctx context.Context) error {
+				return handlers.CheckSES(ctx, region)
+			},
+		})
+	}
+
+	return checker
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spam
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/visiosto/bifrost/internal/config"
+)
+
+const (
+	akismetTimeout          = 5 * time.Second
+	akismetMaxResponseBytes = 1 << 10
+	akismetCommentType      = "contact-form"
+)
+
+var errAkismet = errors.New("akismet comment-check failed")
+
+type akismetRule struct {
+	client *http.Client
+	cfg    *config.SpamAkismet
+}
+
+func newAkismetRule(cfg *config.SpamAkismet) *akismetRule {
+	return &akismetRule{
+		client: &http.Client{Timeout: akismetTimeout}, //nolint:exhaustruct // use defaults
+		cfg:    cfg,
+	}
+}
+
+func (*akismetRule) Name() string {
+	return "akismet"
+}
+
+// Score checks the submission with the comment-check API. See
+// https://akismet.com/developers/detailed-docs/comment-check/.
+func (r *akismetRule) Score(ctx context.Context, sub *Submission) (float64, error) {
+	form := url.Values{}
+	form.Set("api_key", r.cfg.APIKey)
+	form.Set("blog", r.cfg.Blog)
+	form.Set("user_ip", sub.IP)
+	form.Set("user_agent", sub.UserAgent)
+	form.Set("referrer", sub.Referrer)
+	form.Set("comment_type", akismetCommentType)
+	form.Set("comment_content", r.content(sub.Payload))
+
+	if r.cfg.Lang != "" {
+		form.Set("blog_lang", r.cfg.Lang)
+	}
+
+	if s, ok := sub.Payload[r.cfg.AuthorField].(string); ok {
+		form.Set("comment_author", s)
+	}
+
+	if s, ok := sub.Payload[r.cfg.EmailField].(string); ok {
+		form.Set("comment_author_email", s)
+	}
+
+	endpoint := strings.TrimSuffix(r.cfg.BaseURL, "/") + "/1.1/comment-check"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create Akismet request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", errAkismet, err)
+	}
+
+	defer resp.Body.Close() //nolint:errcheck // the body is fully read below
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, akismetMaxResponseBytes))
+	if err != nil {
+		return 0, fmt.Errorf("%w: failed to read response: %w", errAkismet, err)
+	}
+
+	switch string(bytes.TrimSpace(body)) {
+	case "true":
+		return r.cfg.Score, nil
+	case "false":
+		return 0, nil
+	default:
+		return 0, fmt.Errorf(
+			"%w: unexpected response %q (%s): %s",
+			errAkismet,
+			body,
+			resp.Status,
+			resp.Header.Get("X-Akismet-Debug-Help"),
+		)
+	}
+}
+
+func (r *akismetRule) content(payload map[string]any) string {
+	if len(r.cfg.ContentFields) == 0 {
+		return strings.Join(texts(payload), "\n\n")
+	}
+
+	parts := make([]string, 0, len(r.cfg.ContentFields))
+
+	for _, name := range r.cfg.ContentFields {
+		parts = append(parts, texts(payload[name])...)
+	}
+
+	return strings.Join(parts, "\n\n")
+}
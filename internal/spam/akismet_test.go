@@ -0,0 +1,118 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spam_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/visiosto/bifrost/internal/config"
+	"github.com/visiosto/bifrost/internal/spam"
+)
+
+func TestAkismet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		response  string
+		wantScore float64
+		wantSpam  bool
+	}{
+		{name: "spam", response: "true", wantScore: 10, wantSpam: true},
+		{name: "ham", response: "false", wantScore: 0, wantSpam: false},
+		// An unexpected answer fails the rule, and the failed rules are
+		// skipped so that the submission is not blocked.
+		{name: "unexpected body", response: "Missing required field: user_ip.", wantScore: 0, wantSpam: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// The stand-in for the comment-check API checks that it receives
+			// the key, the sender, and the content of the submission.
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/1.1/comment-check" {
+					http.NotFound(w, r)
+
+					return
+				}
+
+				err := r.ParseForm()
+				if err != nil {
+					t.Errorf("failed to parse comment-check request: %v", err)
+				}
+
+				for key, want := range map[string]string{
+					"api_key":              "key",
+					"blog":                 "https://a.example",
+					"user_ip":              "192.0.2.1",
+					"comment_type":         "contact-form",
+					"comment_author":       "Alice",
+					"comment_author_email": "alice@example.com",
+					"comment_content":      "Hello",
+				} {
+					if got := r.PostForm.Get(key); got != want {
+						t.Errorf("comment-check request %s = %q, want %q", key, got, want)
+					}
+				}
+
+				if tt.response != "true" && tt.response != "false" {
+					w.Header().Set("X-Akismet-Debug-Help", "Empty user_ip")
+				}
+
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer srv.Close()
+
+			engine := spam.New(&config.SpamFilter{ //nolint:exhaustruct // only Akismet is enabled
+				Akismet: &config.SpamAkismet{
+					APIKey:        "key",
+					Blog:          "https://a.example",
+					BaseURL:       srv.URL + "/",
+					Lang:          "",
+					AuthorField:   "name",
+					EmailField:    "email",
+					ContentFields: []string{"message"},
+					Score:         10,
+				},
+				Threshold: 5,
+			})
+
+			result := engine.Evaluate(t.Context(), &spam.Submission{
+				Payload: map[string]any{
+					"name":    "Alice",
+					"email":   "alice@example.com",
+					"message": "Hello",
+				},
+				Site:      "s",
+				Form:      "contact",
+				IP:        "192.0.2.1",
+				UserAgent: "test",
+				Referrer:  "",
+			})
+
+			if result.Score != tt.wantScore {
+				t.Errorf("Evaluate() score = %v, want %v", result.Score, tt.wantScore)
+			}
+
+			if result.Spam != tt.wantSpam {
+				t.Errorf("Evaluate() spam = %v, want %v", result.Spam, tt.wantSpam)
+			}
+		})
+	}
+}
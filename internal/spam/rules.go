@@ -0,0 +1,260 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spam
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/visiosto/bifrost/internal/config"
+)
+
+const repeatPruneInterval = time.Minute
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)|\[url[=\]]`)
+
+// Built-in list of common disposable email domains.
+//
+//nolint:gochecknoglobals // constant list of domains
+var disposableDomains = []string{
+	"10minutemail.com",
+	"dispostable.com",
+	"fakeinbox.com",
+	"getnada.com",
+	"guerrillamail.com",
+	"guerrillamail.net",
+	"mailinator.com",
+	"maildrop.cc",
+	"mailnesia.com",
+	"mintemail.com",
+	"mohmal.com",
+	"sharklasers.com",
+	"temp-mail.org",
+	"tempmail.com",
+	"tempmailo.com",
+	"throwawaymail.com",
+	"trashmail.com",
+	"yopmail.com",
+}
+
+type linkRule struct {
+	max   int
+	score float64
+}
+
+type keywordRule struct {
+	keywords []string
+	patterns []*regexp.Regexp
+	score    float64
+}
+
+type scriptRule struct {
+	maxRatio float64
+	score    float64
+}
+
+type disposableRule struct {
+	domains map[string]struct{}
+	score   float64
+}
+
+type repeatRule struct {
+	seen      map[[sha256.Size]byte]time.Time
+	lastPrune time.Time
+	window    time.Duration
+	score     float64
+	mu        sync.Mutex
+}
+
+func newLinkRule(cfg *config.SpamLinks) *linkRule {
+	return &linkRule{max: cfg.Max, score: cfg.Score}
+}
+
+func (*linkRule) Name() string {
+	return "links"
+}
+
+func (r *linkRule) Score(_ context.Context, sub *Submission) (float64, error) {
+	n := 0
+
+	for _, s := range texts(sub.Payload) {
+		n += len(linkPattern.FindAllStringIndex(s, -1))
+	}
+
+	if n <= r.max {
+		return 0, nil
+	}
+
+	return float64(n-r.max) * r.score, nil
+}
+
+func newKeywordRule(cfg *config.SpamKeywords) *keywordRule {
+	keywords := make([]string, 0, len(cfg.Keywords))
+	for _, k := range cfg.Keywords {
+		keywords = append(keywords, strings.ToLower(k))
+	}
+
+	// The patterns are validated when the config is loaded.
+	patterns := make([]*regexp.Regexp, 0, len(cfg.Patterns))
+	for _, p := range cfg.Patterns {
+		patterns = append(patterns, regexp.MustCompile(p))
+	}
+
+	return &keywordRule{keywords: keywords, patterns: patterns, score: cfg.Score}
+}
+
+func (*keywordRule) Name() string {
+	return "keywords"
+}
+
+func (r *keywordRule) Score(_ context.Context, sub *Submission) (float64, error) {
+	text := strings.Join(texts(sub.Payload), "\n")
+	lower := strings.ToLower(text)
+	n := 0
+
+	for _, k := range r.keywords {
+		if strings.Contains(lower, k) {
+			n++
+		}
+	}
+
+	for _, p := range r.patterns {
+		if p.MatchString(text) {
+			n++
+		}
+	}
+
+	return float64(n) * r.score, nil
+}
+
+func newScriptRule(cfg *config.SpamScripts) *scriptRule {
+	return &scriptRule{maxRatio: cfg.MaxRatio, score: cfg.Score}
+}
+
+func (*scriptRule) Name() string {
+	return "scripts"
+}
+
+func (r *scriptRule) Score(_ context.Context, sub *Submission) (float64, error) {
+	letters, foreign := 0, 0
+
+	for _, s := range texts(sub.Payload) {
+		for _, c := range s {
+			if !unicode.IsLetter(c) {
+				continue
+			}
+
+			letters++
+
+			if unicode.In(c, unicode.Cyrillic, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+				foreign++
+			}
+		}
+	}
+
+	if letters == 0 || float64(foreign)/float64(letters) <= r.maxRatio {
+		return 0, nil
+	}
+
+	return r.score, nil
+}
+
+func newDisposableRule(cfg *config.SpamDisposableEmails) *disposableRule {
+	domains := map[string]struct{}{}
+
+	for _, d := range disposableDomains {
+		domains[d] = struct{}{}
+	}
+
+	for _, d := range cfg.Domains {
+		domains[strings.ToLower(d)] = struct{}{}
+	}
+
+	return &disposableRule{domains: domains, score: cfg.Score}
+}
+
+func (*disposableRule) Name() string {
+	return "disposable_emails"
+}
+
+func (r *disposableRule) Score(_ context.Context, sub *Submission) (float64, error) {
+	for _, s := range texts(sub.Payload) {
+		i := strings.LastIndexByte(s, '@')
+		if i < 0 || strings.ContainsAny(s, " \t\n") {
+			continue
+		}
+
+		if _, ok := r.domains[strings.ToLower(s[i+1:])]; ok {
+			return r.score, nil
+		}
+	}
+
+	return 0, nil
+}
+
+func newRepeatRule(cfg *config.SpamRepeats) *repeatRule {
+	return &repeatRule{
+		seen:      map[[sha256.Size]byte]time.Time{},
+		lastPrune: time.Time{},
+		window:    time.Duration(cfg.WindowSeconds) * time.Second,
+		score:     cfg.Score,
+		mu:        sync.Mutex{},
+	}
+}
+
+func (*repeatRule) Name() string {
+	return "repeats"
+}
+
+func (r *repeatRule) Score(_ context.Context, sub *Submission) (float64, error) {
+	// The keys of the maps are sorted when they are encoded so the encoded
+	// payload is the same for identical submissions.
+	data, err := json.Marshal(sub.Payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now.Sub(r.lastPrune) > repeatPruneInterval {
+		for k, expires := range r.seen {
+			if now.After(expires) {
+				delete(r.seen, k)
+			}
+		}
+
+		r.lastPrune = now
+	}
+
+	expires, ok := r.seen[sum]
+	r.seen[sum] = now.Add(r.window)
+
+	if ok && now.Before(expires) {
+		return r.score, nil
+	}
+
+	return 0, nil
+}
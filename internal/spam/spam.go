@@ -0,0 +1,155 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package spam scores the content of the form submissions. The score is
+// the sum of the scores of the rules, and the submissions that reach
+// the threshold of the form are considered spam.
+package spam
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/visiosto/bifrost/internal/config"
+)
+
+// Rule is a single spam scoring rule.
+type Rule interface {
+	// Name returns the name of the rule that is reported in the results.
+	Name() string
+
+	// Score returns the spam score of the submission. Zero means that
+	// the rule did not find anything suspicious.
+	Score(ctx context.Context, sub *Submission) (float64, error)
+}
+
+// Submission is the data that the rules score.
+type Submission struct {
+	Payload   map[string]any
+	Site      string
+	Form      string
+	IP        string
+	UserAgent string
+	Referrer  string
+}
+
+// Match is a rule that added to the score of a submission.
+type Match struct {
+	Rule  string  `json:"rule"`
+	Score float64 `json:"score"`
+}
+
+// Result is the result of scoring a submission.
+type Result struct {
+	Matches []Match `json:"matches"`
+	Score   float64 `json:"score"`
+	Spam    bool    `json:"spam"`
+}
+
+// Engine scores the submissions of a form.
+type Engine struct {
+	rules     []Rule
+	threshold float64
+}
+
+// New returns an Engine with the built-in rules that are enabled in
+// the config.
+func New(cfg *config.SpamFilter) *Engine {
+	e := &Engine{rules: nil, threshold: cfg.Threshold}
+
+	if cfg.Links != nil {
+		e.Add(newLinkRule(cfg.Links))
+	}
+
+	if cfg.Keywords != nil {
+		e.Add(newKeywordRule(cfg.Keywords))
+	}
+
+	if cfg.Scripts != nil {
+		e.Add(newScriptRule(cfg.Scripts))
+	}
+
+	if cfg.DisposableEmails != nil {
+		e.Add(newDisposableRule(cfg.DisposableEmails))
+	}
+
+	if cfg.Repeats != nil {
+		e.Add(newRepeatRule(cfg.Repeats))
+	}
+
+	if cfg.Akismet != nil {
+		e.Add(newAkismetRule(cfg.Akismet))
+	}
+
+	return e
+}
+
+// Add adds a rule to the engine.
+func (e *Engine) Add(rule Rule) {
+	e.rules = append(e.rules, rule)
+}
+
+// Evaluate scores the submission with all of the rules. The rules that fail
+// are logged and skipped so that an unavailable rule does not block
+// the submissions.
+func (e *Engine) Evaluate(ctx context.Context, sub *Submission) *Result {
+	result := &Result{Matches: nil, Score: 0, Spam: false}
+
+	for _, rule := range e.rules {
+		score, err := rule.Score(ctx, sub)
+		if err != nil {
+			slog.WarnContext(ctx, "spam rule failed", "rule", rule.Name(), "site", sub.Site, "form", sub.Form, "err", err)
+
+			continue
+		}
+
+		if score != 0 {
+			result.Matches = append(result.Matches, Match{Rule: rule.Name(), Score: score})
+			result.Score += score
+		}
+	}
+
+	result.Spam = result.Score >= e.threshold
+
+	return result
+}
+
+// texts returns all of the string values in the payload, including the ones
+// in the objects.
+func texts(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case map[string]any:
+		var result []string
+
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			result = append(result, texts(v[k])...)
+		}
+
+		return result
+	case []any:
+		var result []string
+
+		for _, x := range v {
+			result = append(result, texts(x)...)
+		}
+
+		return result
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/visiosto/bifrost/internal/idempotency"
+)
+
+// LoadResponse implements [idempotency.Backend].
+func (s *SQLite) LoadResponse(ctx context.Context, key string) (*idempotency.Response, error) {
+	var resp idempotency.Response
+
+	err := s.db.QueryRowContext(
+		ctx,
+		"SELECT status, content_type, fingerprint, body FROM idempotency WHERE key = ? AND expires_at > ?",
+		key,
+		time.Now().UnixMilli(),
+	).Scan(&resp.Status, &resp.ContentType, &resp.Fingerprint, &resp.Body)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, idempotency.ErrNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query response: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// StoreResponse implements [idempotency.Backend]. The expired responses are
+// deleted at the same time.
+func (s *SQLite) StoreResponse(ctx context.Context, key string, resp *idempotency.Response, expires time.Time) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM idempotency WHERE expires_at <= ?", time.Now().UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to delete expired responses: %w", err)
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		`INSERT OR REPLACE INTO idempotency (key, status, content_type, fingerprint, body, expires_at)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+		key,
+		resp.Status,
+		resp.ContentType,
+		resp.Fingerprint,
+		resp.Body,
+		expires.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,351 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // register the SQLite driver
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS submissions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	site TEXT NOT NULL,
+	form TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	received_at INTEGER NOT NULL,
+	request_id TEXT NOT NULL,
+	delivery_status TEXT NOT NULL,
+	delivery_error TEXT NOT NULL DEFAULT '',
+	spam_verdict TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS submissions_site_form_received_at
+	ON submissions (site, form, received_at);
+
+CREATE TABLE IF NOT EXISTS idempotency (
+	key TEXT PRIMARY KEY,
+	status INTEGER NOT NULL,
+	content_type TEXT NOT NULL,
+	fingerprint TEXT NOT NULL,
+	body BLOB NOT NULL,
+	expires_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_expires_at ON idempotency (expires_at);
+
+CREATE TABLE IF NOT EXISTS healthcheck (
+	id INTEGER PRIMARY KEY,
+	checked_at INTEGER NOT NULL
+);
+`
+
+const sqliteColumns = "id, site, form, payload, received_at, request_id, delivery_status, delivery_error, spam_verdict"
+
+// SQLite is a [Store] that keeps the submissions in a local SQLite database.
+type SQLite struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens the SQLite database at the given path and creates
+// the tables if needed.
+func OpenSQLite(ctx context.Context, path string) (*SQLite, error) {
+	dsn := "file:" + filepath.ToSlash(filepath.Clean(path)) +
+		"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database at %q: %w", path, err)
+	}
+
+	// SQLite allows only one writer at a time so there is no use in having
+	// more connections.
+	db.SetMaxOpenConns(1)
+
+	_, err = db.ExecContext(ctx, sqliteSchema)
+	if err != nil {
+		_ = db.Close()
+
+		return nil, fmt.Errorf("failed to create SQLite schema: %w", err)
+	}
+
+	slog.InfoContext(ctx, "opened SQLite storage", "path", path)
+
+	return &SQLite{db: db}, nil
+}
+
+// Save implements [Store].
+func (s *SQLite) Save(ctx context.Context, sub *Submission) error {
+	payload, err := json.Marshal(sub.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode submission payload: %w", err)
+	}
+
+	res, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO submissions
+			(site, form, payload, received_at, request_id, delivery_status, delivery_error, spam_verdict)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		sub.Site,
+		sub.Form,
+		string(payload),
+		sub.ReceivedAt.UnixMilli(),
+		sub.RequestID,
+		sub.DeliveryStatus,
+		sub.DeliveryError,
+		sub.SpamVerdict,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert submission: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get submission ID: %w", err)
+	}
+
+	sub.ID = id
+
+	return nil
+}
+
+// UpdateDelivery implements [Store].
+func (s *SQLite) UpdateDelivery(ctx context.Context, id int64, status, message string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		"UPDATE submissions SET delivery_status = ?, delivery_error = ? WHERE id = ?",
+		status,
+		message,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update delivery status of submission %d: %w", id, err)
+	}
+
+	return nil
+}
+
+// Get implements [Store].
+func (s *SQLite) Get(ctx context.Context, id int64) (*Submission, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+sqliteColumns+" FROM submissions WHERE id = ?", id)
+
+	sub, err := scanSubmission(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// List implements [Store].
+func (s *SQLite) List(ctx context.Context, filter *Filter) ([]*Submission, error) {
+	where, args := sqliteWhere(filter)
+	query := "SELECT " + sqliteColumns + " FROM submissions" + where + " ORDER BY received_at DESC, id DESC"
+
+	if filter.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query submissions: %w", err)
+	}
+
+	defer rows.Close() //nolint:errcheck // the errors are reported by rows.Err
+
+	result := []*Submission{}
+
+	for rows.Next() {
+		var sub *Submission
+
+		sub, err = scanSubmission(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, sub)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read submissions: %w", err)
+	}
+
+	return result, nil
+}
+
+// Delete implements [Store].
+func (s *SQLite) Delete(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM submissions WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete submission %d: %w", id, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get the number of deleted submissions: %w", err)
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+
+	return nil
+}
+
+// DeleteMatching implements [Store].
+func (s *SQLite) DeleteMatching(ctx context.Context, filter *Filter) (int64, error) {
+	where, args := sqliteWhere(filter)
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM submissions"+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete submissions: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the number of deleted submissions: %w", err)
+	}
+
+	return n, nil
+}
+
+// Ping implements [Store].
+func (s *SQLite) Ping(ctx context.Context) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO healthcheck (id, checked_at) VALUES (1, ?)
+			ON CONFLICT (id) DO UPDATE SET checked_at = excluded.checked_at`,
+		time.Now().UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write to SQLite database: %w", err)
+	}
+
+	return nil
+}
+
+// Close implements [Store].
+func (s *SQLite) Close() error {
+	err := s.db.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close SQLite database: %w", err)
+	}
+
+	return nil
+}
+
+// scanner is the common interface of [sql.Row] and [sql.Rows].
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSubmission(row scanner) (*Submission, error) {
+	var (
+		sub        Submission
+		payload    string
+		receivedAt int64
+	)
+
+	err := row.Scan(
+		&sub.ID,
+		&sub.Site,
+		&sub.Form,
+		&payload,
+		&receivedAt,
+		&sub.RequestID,
+		&sub.DeliveryStatus,
+		&sub.DeliveryError,
+		&sub.SpamVerdict,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan submission: %w", err)
+	}
+
+	err = json.Unmarshal([]byte(payload), &sub.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode payload of submission %d: %w", sub.ID, err)
+	}
+
+	sub.ReceivedAt = time.UnixMilli(receivedAt).UTC()
+
+	return &sub, nil
+}
+
+func sqliteWhere(filter *Filter) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+
+	if filter.Site != "" {
+		conds = append(conds, "site = ?")
+		args = append(args, filter.Site)
+	}
+
+	if filter.Form != "" {
+		conds = append(conds, "form = ?")
+		args = append(args, filter.Form)
+	}
+
+	if filter.DeliveryStatus != "" {
+		conds = append(conds, "delivery_status = ?")
+		args = append(args, filter.DeliveryStatus)
+	}
+
+	if filter.SpamVerdict != "" {
+		conds = append(conds, "spam_verdict = ?")
+		args = append(args, filter.SpamVerdict)
+	}
+
+	if !filter.From.IsZero() {
+		conds = append(conds, "received_at >= ?")
+		args = append(args, filter.From.UnixMilli())
+	}
+
+	if !filter.To.IsZero() {
+		conds = append(conds, "received_at < ?")
+		args = append(args, filter.To.UnixMilli())
+	}
+
+	if filter.Value != "" {
+		conds = append(
+			conds,
+			"EXISTS (SELECT 1 FROM json_tree(submissions.payload) WHERE type = 'text' AND lower(value) = lower(?))",
+		)
+		args = append(args, filter.Value)
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package storage defines the storage for the accepted form submissions.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/visiosto/bifrost/internal/config"
+)
+
+// Delivery statuses of the submissions.
+const (
+	DeliveryPending = "pending"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+)
+
+// Spam verdicts of the submissions.
+const (
+	VerdictHam  = "ham"
+	VerdictSpam = "spam"
+)
+
+// ErrNotFound is returned when the requested submission does not exist.
+var ErrNotFound = errors.New("submission not found")
+
+var errUnknownStorage = errors.New("unknown storage type")
+
+// Store stores the form submissions.
+type Store interface { //nolint:interfacebloat // the storage needs the full set of operations
+
+	// Save stores a new submission and sets its ID.
+	Save(ctx context.Context, s *Submission) error
+
+	// UpdateDelivery sets the delivery status of the submission with the given
+	// ID. The message should contain the error if the delivery failed.
+	UpdateDelivery(ctx context.Context, id int64, status, message string) error
+
+	// Get returns the submission with the given ID or [ErrNotFound].
+	Get(ctx context.Context, id int64) (*Submission, error)
+
+	// List returns the submissions that match the filter, newest first.
+	List(ctx context.Context, filter *Filter) ([]*Submission, error)
+
+	// Delete deletes the submission with the given ID or returns
+	// [ErrNotFound].
+	Delete(ctx context.Context, id int64) error
+
+	// DeleteMatching deletes all of the submissions that match the filter and
+	// returns the number of the deleted submissions. The limit and offset of
+	// the filter are ignored.
+	DeleteMatching(ctx context.Context, filter *Filter) (int64, error)
+
+	// Ping checks that the storage is reachable and writable.
+	Ping(ctx context.Context) error
+
+	// Close closes the storage.
+	Close() error
+}
+
+// Submission is an accepted form submission.
+type Submission struct {
+	ReceivedAt     time.Time      `json:"receivedAt"`
+	Payload        map[string]any `json:"payload"`
+	Site           string         `json:"site"`
+	Form           string         `json:"form"`
+	RequestID      string         `json:"requestId"`
+	DeliveryStatus string         `json:"deliveryStatus"`
+	DeliveryError  string         `json:"deliveryError,omitempty"`
+	SpamVerdict    string         `json:"spamVerdict"`
+	ID             int64          `json:"id"`
+}
+
+// Filter selects the submissions to list. The zero values of the fields match
+// all of the submissions.
+type Filter struct {
+	// From is the inclusive lower bound of the receive time.
+	From time.Time
+
+	// To is the exclusive upper bound of the receive time.
+	To time.Time
+
+	Site           string
+	Form           string
+	DeliveryStatus string
+	SpamVerdict    string
+
+	// Value matches the submissions that contain a string value equal to this
+	// anywhere in the payload, ignoring case. It is used to find the data of
+	// a person by their email address.
+	Value string
+
+	// Limit is the maximum number of the returned submissions. Zero means no
+	// limit.
+	Limit  int
+	Offset int
+}
+
+// Open opens the storage according to the config. It returns nil if
+// the storage is not configured.
+func Open(ctx context.Context, cfg *config.Storage) (Store, error) { //nolint:ireturn // storage is chosen by config
+	switch cfg.Type {
+	case "":
+		return nil, nil //nolint:nilnil // no storage configured
+	case config.StorageSQLite:
+		store, err := OpenSQLite(ctx, cfg.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		return store, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnknownStorage, cfg.Type)
+	}
+}
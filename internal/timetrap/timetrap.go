@@ -0,0 +1,120 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package timetrap issues and verifies the signed form timestamps that are
+// used to catch the submissions that are sent faster than a human could
+// fill in the form.
+package timetrap
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrMalformed is returned when the token cannot be parsed.
+	ErrMalformed = errors.New("malformed timestamp token")
+
+	// ErrSignature is returned when the signature of the token is invalid or
+	// the token was issued for another form.
+	ErrSignature = errors.New("invalid timestamp token signature")
+
+	// ErrTooEarly is returned when the form was submitted too soon after
+	// the token was issued.
+	ErrTooEarly = errors.New("form submitted too early")
+
+	// ErrExpired is returned when the token is too old.
+	ErrExpired = errors.New("timestamp token expired")
+)
+
+// Signer issues and verifies the timestamp tokens of a site.
+type Signer struct {
+	key []byte
+}
+
+// NewSigner returns a Signer that uses the given secret.
+func NewSigner(secret string) *Signer {
+	return &Signer{key: []byte(secret)}
+}
+
+// Issue returns a new token for the form that embeds the site and form IDs
+// and the issue time t.
+func (s *Signer) Issue(site, form string, t time.Time) string {
+	payload := site + "/" + form + "/" + strconv.FormatInt(t.Unix(), 10)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(s.sign(payload))
+}
+
+// Verify checks that the token was issued by s for the form and that its age
+// at now is between minAge and maxAge.
+func (s *Signer) Verify(token, site, form string, now time.Time, minAge, maxAge time.Duration) error {
+	encPayload, encSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrMalformed
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return ErrMalformed
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(encSig)
+	if err != nil {
+		return ErrMalformed
+	}
+
+	if !hmac.Equal(sig, s.sign(string(payload))) {
+		return ErrSignature
+	}
+
+	i := strings.LastIndexByte(string(payload), '/')
+	if i < 0 {
+		return ErrMalformed
+	}
+
+	if string(payload[:i]) != site+"/"+form {
+		return ErrSignature
+	}
+
+	issued, err := strconv.ParseInt(string(payload[i+1:]), 10, 64)
+	if err != nil {
+		return ErrMalformed
+	}
+
+	age := now.Sub(time.Unix(issued, 0))
+
+	if age < minAge {
+		return fmt.Errorf("%w: age %s is less than %s", ErrTooEarly, age, minAge)
+	}
+
+	if age > maxAge {
+		return fmt.Errorf("%w: age %s is more than %s", ErrExpired, age, maxAge)
+	}
+
+	return nil
+}
+
+func (s *Signer) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing sets up the OpenTelemetry tracing of Bifröst.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/visiosto/bifrost/internal/config"
+	"github.com/visiosto/bifrost/internal/version"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName is the name of the service that is reported in the traces.
+const ServiceName = "bifrost"
+
+// Tracer returns the named tracer from the global tracer provider. If tracing
+// is not set up, the returned tracer does nothing.
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
+// Setup sets up the global OpenTelemetry tracer provider and propagator
+// according to the given config. The incoming trace context is always
+// propagated, but the spans are exported only if the OTLP endpoint is
+// configured. The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, cfg *config.Tracing) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(
+		ctx,
+		otlptracehttp.WithEndpointURL(cfg.Endpoint),
+		otlptracehttp.WithHeaders(cfg.Headers),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", ServiceName),
+		attribute.String("service.version", version.Version.String()),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(*cfg.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	slog.InfoContext(ctx, "exporting traces", "endpoint", cfg.Endpoint, "sampleRatio", *cfg.SampleRatio)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to shut down tracer provider: %w", err)
+		}
+
+		return nil
+	}, nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/visiosto/bifrost/internal/config"
+	"github.com/visiosto/bifrost/internal/tracing"
+)
+
+type export struct {
+	header http.Header
+	body   []byte
+}
+
+func TestSetupExportsSpans(t *testing.T) {
+	t.Parallel()
+
+	exports := make(chan export, 10)
+
+	// The collector accepts the OTLP/HTTP requests in process. The spans are
+	// encoded as protobuf, so the names can be found in the raw body.
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		exports <- export{header: r.Header.Clone(), body: body}
+
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	ratio := 1.0
+
+	shutdown, err := tracing.Setup(t.Context(), &config.Tracing{
+		Endpoint:    collector.URL + "/v1/traces",
+		Headers:     map[string]string{"Authorization": "Bearer secret"},
+		SampleRatio: &ratio,
+	})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+
+	ctx, parent := tracing.Tracer("test").Start(t.Context(), "test request")
+	_, child := tracing.Tracer("test").Start(ctx, "test notifier send")
+
+	child.End()
+	parent.End()
+
+	// Shutting down flushes the batched spans to the collector.
+	err = shutdown(t.Context())
+	if err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+
+	close(exports)
+
+	var body []byte
+
+	for e := range exports {
+		if got := e.header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization header = %q, want %q", got, "Bearer secret")
+		}
+
+		body = append(body, e.body...)
+	}
+
+	for _, name := range []string{"test request", "test notifier send", tracing.ServiceName} {
+		if !bytes.Contains(body, []byte(name)) {
+			t.Errorf("exported spans do not contain %q", name)
+		}
+	}
+}
+
+func TestSetupWithoutEndpoint(t *testing.T) {
+	t.Parallel()
+
+	shutdown, err := tracing.Setup(t.Context(), &config.Tracing{}) //nolint:exhaustruct // tracing disabled
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+
+	err = shutdown(t.Context())
+	if err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+}
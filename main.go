@@ -30,7 +30,9 @@ import (
 	"time"
 
 	"github.com/visiosto/bifrost/internal/config"
+	"github.com/visiosto/bifrost/internal/logging"
 	"github.com/visiosto/bifrost/internal/server"
+	"github.com/visiosto/bifrost/internal/tracing"
 	"github.com/visiosto/bifrost/internal/version"
 )
 
@@ -38,19 +40,45 @@ func main() {
 	ctx := context.Background()
 
 	if len(os.Args) > 1 {
-		if os.Args[1] == "version" {
+		switch os.Args[1] {
+		case "version":
 			_, err := fmt.Fprintf(os.Stdout, "bifrost version %s\n", version.Version.ComparableString())
 			if err != nil {
 				log.Fatal(err)
 			}
 
+			return
+		case "gdpr":
+			err := runGDPR(ctx, os.Args[2:])
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			return
+		case "export":
+			err := runExport(ctx, os.Args[2:])
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			return
+		case "schema":
+			err := runSchema(os.Args[2:])
+			if err != nil {
+				log.Fatal(err)
+			}
+
 			return
 		}
 	}
 
 	cfgPath := flag.String("config", "/etc/bifrost.json", "path to the config file")
 	listenAddr := flag.String("listen-address", "", "bind the server to the given address")
+	adminListenAddr := flag.String("admin-listen-address", "", "bind the admin server to the given address")
 	logLevelName := flag.String("log-level", "", "log only messages with the given severity or more")
+	logFormatName := flag.String("log-format", "", "write the logs in the given format, either text or json")
+	logOutput := flag.String("log-output", "", "write the logs to stdout, stderr, or the file at the given path")
+	otlpEndpoint := flag.String("otlp-endpoint", "", "export traces to the given OTLP/HTTP endpoint URL")
 
 	flag.Parse()
 
@@ -63,6 +91,19 @@ func main() {
 		cfg.ListenAddr = *listenAddr
 	}
 
+	if *adminListenAddr != "" {
+		cfg.Admin.ListenAddr = *adminListenAddr
+	}
+
+	err = cfg.CheckListenAddrs()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *otlpEndpoint != "" {
+		cfg.Tracing.Endpoint = *otlpEndpoint
+	}
+
 	if *logLevelName != "" {
 		err = cfg.LogLevel.UnmarshalText([]byte(*logLevelName))
 		if err != nil {
@@ -70,14 +111,30 @@ func main() {
 		}
 	}
 
-	slog.SetDefault(
-		slog.New(
-			slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{ //nolint:exhaustruct // no need for value
-				AddSource: false,
-				Level:     cfg.LogLevel,
-			}),
-		),
-	)
+	if *logFormatName != "" {
+		err = cfg.LogFormat.UnmarshalText([]byte(*logFormatName))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *logOutput != "" {
+		cfg.LogOutput = *logOutput
+	}
+
+	logCtx, stopLogging := context.WithCancel(ctx)
+
+	logger, closeLog, err := logging.New(logCtx, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := tracing.Setup(ctx, &cfg.Tracing)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	srv, err := server.New(ctx, cfg)
 	if err != nil {
@@ -110,5 +167,17 @@ func main() {
 		log.Fatal(err) //nolint:gocritic // we don't care about the cancel just now
 	}
 
+	err = shutdownTracing(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	slog.InfoContext(ctx, "shutdown complete")
+
+	stopLogging()
+
+	err = closeLog()
+	if err != nil {
+		log.Fatal(err)
+	}
 }
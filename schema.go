@@ -0,0 +1,94 @@
+// Copyright 2025 Visiosto oy
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/visiosto/bifrost/internal/config"
+	"github.com/visiosto/bifrost/internal/schema"
+)
+
+// Output formats of the "schema" command.
+const (
+	schemaFormatJSON       = "json"
+	schemaFormatTypeScript = "typescript"
+)
+
+// runSchema runs the "schema" command that writes the JSON Schema or
+// the TypeScript declaration of the payload of a form to stdout.
+func runSchema(args []string) error {
+	flags := flag.NewFlagSet("schema", flag.ExitOnError)
+	cfgPath := flags.String("config", "/etc/bifrost.json", "path to the config file")
+	siteID := flags.String("site", "", "ID of the site of the form")
+	formID := flags.String("form", "", "ID of the form")
+	format := flags.String("format", schemaFormatJSON, "output format, either json or typescript")
+	typeName := flags.String("type-name", "", "name of the TypeScript interface, derived from the form ID by default")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	if *siteID == "" || *formID == "" {
+		return fmt.Errorf("%w: usage: bifrost schema -site <id> -form <id> [-format json|typescript]", errUsage)
+	}
+
+	cfg, err := config.Load(*cfgPath)
+	if err != nil {
+		return err
+	}
+
+	form, err := findForm(cfg, *siteID, *formID)
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+
+	switch *format {
+	case schemaFormatJSON:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		err = enc.Encode(schema.JSONSchema(form))
+		if err != nil {
+			return fmt.Errorf("failed to write schema: %w", err)
+		}
+	case schemaFormatTypeScript:
+		name := *typeName
+		if name == "" {
+			name = schema.TypeName(form)
+		}
+
+		err = schema.TypeScript(out, name, form)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: unknown format %q", errUsage, *format)
+	}
+
+	err = out.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	return nil
+}